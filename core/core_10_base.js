@@ -47,11 +47,25 @@ $.system = {};
 $.system.log = new 'CC.log';
 $.system.checkpoint = new 'CC.checkpoint';
 $.system.shutdown = new 'CC.shutdown';
+$.system.reloadConfig = new 'CC.reloadConfig';
 $.system.connectionListen = new 'CC.connectionListen';
 $.system.connectionUnlisten = new 'CC.connectionUnlisten';
 $.system.connectionWrite = new 'CC.connectionWrite';
 $.system.connectionClose = new 'CC.connectionClose';
 $.system.xhr = new 'CC.xhr';
+$.system.subscribe = new 'CC.subscribe';
+$.system.unsubscribe = new 'CC.unsubscribe';
+$.system.publish = new 'CC.publish';
+$.system.observe = new 'CC.observe';
+$.system.unobserve = new 'CC.unobserve';
+$.system.setMaintenance = new 'CC.setMaintenance';
+$.system.getMaintenance = new 'CC.getMaintenance';
+$.system.slowTasks = new 'CC.slowTasks';
+$.system.blockedThreads = new 'CC.blockedThreads';
+$.system.lint = new 'CC.lint';
+$.system.format = new 'CC.format';
+$.system.patchFunction = new 'CC.patchFunction';
+$.system.admins = [];
 $.system.onStartup = function onStartup() {
   /* Do things needed at database start, when starting from a .js dump
    * rather than from a .city snapshot (which preserves threads,
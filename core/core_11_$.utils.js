@@ -119,7 +119,9 @@ $.utils.object.spider = function spider(start, callback) {
    *     properties of the current object are not traversed.
    */
   var thread = Thread.current();
-  thread.setTimeLimit(Math.min(thread.getTimeLimit(), 100));
+  // A time limit of 0 (e.g. for threads started by the eval server)
+  // means no limit; leave it that way.
+  if (thread.getTimeLimit() > 100) thread.setTimeLimit(100);
   var seen = new WeakMap();
   var path = [];
   doSpider(start);
@@ -191,6 +193,80 @@ $.utils.object.transplantProperties = function transplantProperties(oldObject, n
     }
   }
 };
+$.utils.object.deepCopy = function deepCopy(object, options) {
+  /* Make a deep copy of an object graph, preserving prototypes,
+   * property attributes, extensibility and cycles.
+   *
+   * Arguments:
+   * object: any: The value to copy.  Primitives are returned as-is.
+   * options: Object|undefined: Optional settings:
+   *   shouldCopy: function(object, Array<string>): boolean:
+   *       Called for each object found (other than object itself) with
+   *       the object and the names of properties from object to get to
+   *       it.  If it returns false the object is shared rather than
+   *       copied.  By default only objects with the same owner as
+   *       object are copied, so copying stops at ownership boundaries.
+   *   onCopy: function(object, object): undefined:
+   *       Called with each original object and its copy, once all of
+   *       the copy's properties have been set.
+   *
+   * Functions cannot be duplicated and are always shared, as are
+   * prototypes (which are not traversed).
+   */
+  options = options || {};
+  if (!$.utils.isObject(object)) return object;
+  var owner = Object.getOwnerOf(object);
+  var shouldCopy = options.shouldCopy || function(obj) {
+    return Object.getOwnerOf(obj) === owner;
+  };
+  var copies = new WeakMap();
+  var path = [];
+  return doCopy(object);
+
+  function doCopy(obj) {
+    if (!$.utils.isObject(obj) || typeof obj === 'function') return obj;
+    if (copies.has(obj)) return copies.get(obj);
+    if (obj !== object && !shouldCopy(obj, path)) return obj;
+
+    var proto = Object.getPrototypeOf(obj);
+    var copy;
+    if (Array.isArray(obj)) {
+      copy = [];
+      Object.setPrototypeOf(copy, proto);
+    } else if (obj instanceof Date) {
+      copy = new Date(obj.getTime());
+      Object.setPrototypeOf(copy, proto);
+    } else if (obj instanceof RegExp) {
+      copy = new RegExp(obj.source, (obj.global ? 'g' : '') +
+          (obj.ignoreCase ? 'i' : '') + (obj.multiline ? 'm' : ''));
+      Object.setPrototypeOf(copy, proto);
+    } else {
+      copy = Object.create(proto);
+    }
+    copies.set(obj, copy);
+
+    var keys = Object.getOwnPropertyNames(obj);
+    for (var i = 0; i < keys.length; i++) {
+      var key = keys[i];
+      var pd = Object.getOwnPropertyDescriptor(obj, key);
+      path.push(key);
+      try {
+        pd.value = doCopy(pd.value);
+      } finally {
+        path.pop();
+      }
+      Object.defineProperty(copy, key, pd);
+    }
+    if (!Object.isExtensible(obj)) Object.preventExtensions(copy);
+    if (options.onCopy) options.onCopy(obj, copy);
+    return copy;
+  }
+};
+Object.setOwnerOf($.utils.object.deepCopy, $.physicals.Maximilian);
+Object.setOwnerOf($.utils.object.deepCopy.prototype, $.physicals.Maximilian);
+$.utils.object.deepEqual = new 'CC.deepEqual';
+$.utils.object.encode = new 'CC.encode';
+$.utils.object.decode = new 'CC.decode';
 $.utils.object.getValue = function getValue(object, prop) {
   /* Get the value from an object's property.
    * If the value is a function, call it and return the result.
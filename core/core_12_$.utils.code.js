@@ -453,6 +453,7 @@ $.utils.code.getGlobal = function getGlobal() {
   global.$ = $;
   global.Array = Array;
   global.Boolean = Boolean;
+  global.clearInterval = clearInterval;
   global.clearTimeout = clearTimeout;
   global.Date = Date;
   global.decodeURI = decodeURI;
@@ -467,16 +468,20 @@ $.utils.code.getGlobal = function getGlobal() {
   global.isFinite = isFinite;
   global.isNaN = isNaN;
   global.JSON = JSON;
+  global.Map = Map;
   global.Math = Math;
   global.Number = Number;
   global.Object = Object;
   global.parseFloat = parseFloat;
   global.parseInt = parseInt;
   global.perms = perms;
+  global.Promise = Promise;
   global.RangeError = RangeError;
   global.ReferenceError = ReferenceError;
   global.RegExp = RegExp;
+  global.Set = Set;
   global.setPerms = setPerms;
+  global.setInterval = setInterval;
   global.setTimeout = setTimeout;
   global.String = String;
   global.suspend = suspend;
@@ -487,9 +492,207 @@ $.utils.code.getGlobal = function getGlobal() {
   global.URIError = URIError;
   global.user = user;
   global.WeakMap = WeakMap;
+  global.WeakSet = WeakSet;
   return global;
 };
 Object.setOwnerOf($.utils.code.getGlobal, $.physicals.Maximilian);
+$.utils.code.recordEdit = function recordEdit(oldValue, newValue, user) {
+  /* Record that newValue is about to replace oldValue as the result
+   * of an edit by user.  Functions are annotated with the time and
+   * author of the edit, and with a reference to the function they
+   * replace, so that the edit can later be rolled back.  (Calls
+   * already in progress continue to run the old function.)
+   */
+  if (typeof newValue !== 'function') return;
+  newValue.lastModifiedTime = Date.now();
+  if (user) {
+    newValue.lastModifiedUser = user;
+  } else {
+    delete newValue.lastModifiedUser;
+  }
+  if (typeof oldValue === 'function' && oldValue !== newValue) {
+    newValue.previousVersion = oldValue;
+  } else {
+    delete newValue.previousVersion;
+  }
+};
+Object.setOwnerOf($.utils.code.recordEdit, $.physicals.Neil);
+$.utils.code.editDataKeys = [];
+$.utils.code.editDataKeys[0] = 'lastModifiedTime';
+$.utils.code.editDataKeys[1] = 'lastModifiedUser';
+$.utils.code.editDataKeys[2] = 'previousVersion';
+$.utils.code.patch = function patch(func, replacement, user) {
+  /* Replace the code of func with that of replacement, as the result
+   * of an edit by user.  Future calls to func will run the new code,
+   * while calls already in progress finish running the old code.
+   * func keeps its identity, closure and other properties (including
+   * .length and .name), so references to it and any state it closes
+   * over are unaffected.  The old code is preserved, as a function
+   * recorded as func.previousVersion, so that the edit can later be
+   * rolled back with revert.
+   *
+   * Returns true if func was patched, or false (having done nothing)
+   * if func and replacement are not both user-defined functions.
+   */
+  var previous = $.system.patchFunction(func, replacement);
+  if (!previous) return false;
+  // The old code takes its edit data with it.
+  var keys = $.utils.code.editDataKeys;
+  for (var i = 0; i < keys.length; i++) {
+    if (Object.prototype.hasOwnProperty.call(func, keys[i])) {
+      previous[keys[i]] = func[keys[i]];
+    }
+  }
+  $.utils.code.recordEdit(previous, func, user);
+  return true;
+};
+Object.setOwnerOf($.utils.code.patch, $.physicals.Neil);
+$.utils.code.history = function history(func) {
+  /* Return the edit history of func, as recorded by recordEdit: an
+   * array of {value, time, user} records, most recent first, where
+   * value is the function as it was after that edit.
+   */
+  var versions = [];
+  var seen = [];
+  for (var f = func; typeof f === 'function'; f = f.previousVersion) {
+    if (seen.indexOf(f) !== -1) break;  // Guard against cycles.
+    seen.push(f);
+    versions.push(
+        {value: f, time: f.lastModifiedTime, user: f.lastModifiedUser});
+  }
+  return versions;
+};
+Object.setOwnerOf($.utils.code.history, $.physicals.Neil);
+$.utils.code.revert = function revert(object, name, opt_version) {
+  /* Revert the function object[name] to an earlier version.
+   * opt_version is an index into the array returned by history
+   * (default 1, i.e. the version before the most recent edit).
+   * If possible the function is patched in place (see patch), so
+   * that it keeps its identity; otherwise object[name] is replaced.
+   * Either way the edits being undone are discarded from the
+   * history.  Returns the restored function.
+   */
+  var version = (opt_version === undefined) ? 1 : opt_version;
+  var current = object[name];
+  var versions = $.utils.code.history(current);
+  if (version < 1 || version >= versions.length) {
+    throw new RangeError('No version ' + version + ' of ' + name);
+  }
+  var restored = versions[version].value;
+  if (!$.system.patchFunction(current, restored)) {
+    object[name] = restored;
+    return restored;
+  }
+  var keys = $.utils.code.editDataKeys;
+  for (var i = 0; i < keys.length; i++) {
+    if (Object.prototype.hasOwnProperty.call(restored, keys[i])) {
+      current[keys[i]] = restored[keys[i]];
+    } else {
+      delete current[keys[i]];
+    }
+  }
+  return current;
+};
+Object.setOwnerOf($.utils.code.revert, $.physicals.Neil);
+$.utils.code.exportOwned = function exportOwned(owner) {
+  /* Return JavaScript source which, when evaluated, will recreate the
+   * objects reachable from $ which are owned by owner.  This allows
+   * an individual builder to back up their creations or transplant
+   * them into another world.
+   *
+   * References to objects belonging to anyone else are written as
+   * selectors (e.g. $.physicals.Neil), to be resolved in the world
+   * into which the source is loaded.  References to objects not
+   * reachable from $ are written as undefined (with a comment).
+   */
+  var found = [];
+  var selectors = new WeakMap();
+  $.utils.object.spider($, function(object, path) {
+    var selector = new $.Selector(['$'].concat(path));
+    selectors.set(object, selector);
+    if (Object.getOwnerOf(object) === owner) {
+      found.push({object: object, selector: selector});
+    }
+    return false;
+  });
+
+  function ref(value) {
+    if (!$.utils.isObject(value)) return $.utils.code.toSource(value);
+    var selector = Object.getOwnerOf(value) === owner ?
+        selectors.get(value) :
+        ($.Selector.for(value) || selectors.get(value));
+    return selector ? selector.toExpr() : 'undefined  /* no known selector */';
+  }
+
+  var out = [];
+  // Create all the objects first, so they can refer to each other.
+  for (var i = 0; i < found.length; i++) {
+    var obj = found[i].object;
+    var expr = found[i].selector.toExpr();
+    var proto = Object.getPrototypeOf(obj);
+    var defaultProto;
+    var create;
+    if (typeof obj === 'function') {
+      create = Function.prototype.toString.call(obj);
+      defaultProto = Function.prototype;
+    } else if (Array.isArray(obj)) {
+      create = '[]';
+      defaultProto = Array.prototype;
+    } else if (proto === Date.prototype || proto === RegExp.prototype) {
+      create = $.utils.code.toSource(obj);
+      defaultProto = proto;
+    } else {
+      create = '{}';
+      defaultProto = Object.prototype;
+    }
+    out.push(expr + ' = ' + create + ';');
+    if (proto !== defaultProto) {
+      out.push('Object.setPrototypeOf(' + expr + ', ' + ref(proto) + ');');
+    }
+    out.push('Object.setOwnerOf(' + expr + ', ' + ref(owner) + ');');
+  }
+  // Then fill in their properties.
+  for (i = 0; i < found.length; i++) {
+    obj = found[i].object;
+    var keys = Object.getOwnPropertyNames(obj);
+    for (var j = 0; j < keys.length; j++) {
+      var key = keys[j];
+      if (typeof obj === 'function' && (key === 'length' || key === 'name')) {
+        continue;
+      } else if (Array.isArray(obj) && key === 'length') {
+        continue;
+      }
+      var selector = new $.Selector(found[i].selector.concat(key));
+      var value = obj[key];
+      // Does the property have other attributes than it would get from
+      // a plain assignment?  (A function's .prototype is created
+      // non-enumerable and non-configurable.)
+      var pd = Object.getOwnPropertyDescriptor(obj, key);
+      var isProto = typeof obj === 'function' && key === 'prototype';
+      var plain = pd.writable && pd.enumerable === !isProto &&
+          pd.configurable === !isProto;
+      var attributes = 'writable: ' + pd.writable +
+          ', enumerable: ' + pd.enumerable +
+          ', configurable: ' + pd.configurable + '}';
+      var define = 'Object.defineProperty(' + found[i].selector.toExpr() +
+          ', ' + $.utils.code.toSource(key) + ', {';
+      // Skip objects that were created at this location above (except
+      // to fix up their attributes).
+      if ($.utils.isObject(value) &&
+          String(selectors.get(value)) === String(selector)) {
+        if (!plain) out.push(define + attributes + ');');
+        continue;
+      }
+      if (plain) {
+        out.push(selector.toSetExpr(ref(value)) + ';');
+      } else {
+        out.push(define + 'value: ' + ref(value) + ', ' + attributes + ');');
+      }
+    }
+  }
+  return out.join('\n') + '\n';
+};
+Object.setOwnerOf($.utils.code.exportOwned, $.physicals.Neil);
 $.utils.code.parse = new 'CC.acorn.parse';
 $.utils.code.parseExpressionAt = new 'CC.acorn.parseExpressionAt';
 
@@ -66,14 +66,17 @@ $.http['code.']['/inlineEdit'].save = function save(obj, key, src) {
   // functions; generate other values from an Acorn parse tree.
   var evalGlobal = eval;
   var val = evalGlobal(src);
+  // Patch an existing function in place, so that references to it
+  // and any state it closes over survive the edit.
+  if (Object.prototype.hasOwnProperty.call(obj, key) &&
+      $.utils.code.patch(old, val)) {
+    return this.load(obj, key);
+  }
   if (typeof old === 'function' && typeof val === 'function') {
     $.utils.object.transplantProperties(old, val);
   }
-  if (typeof val === 'function') {
-    val.lastModifiedTime = Date.now();
-    // TODO: Add user.
-    //val.lastModifiedUser = ...;
-  }
+  // TODO: Add user.
+  $.utils.code.recordEdit(old, val);
   obj[key] = val;
   return this.load(obj, key);
 };
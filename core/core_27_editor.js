@@ -407,10 +407,28 @@ $.http['code.']['/editorXhr'].save = function $_www_code_editor_save(src, bindin
       throw e;  // Rethrow real errors.
     }
   }
-  // Record last modification data on functions.
-  if (typeof saveValue === 'function') {
-    saveValue.lastModifiedTime = Date.now();
-    saveValue.lastModifiedUser = user;
+  if ((binding.isProp() || binding.isVar()) &&
+      $.utils.code.patch(oldValue, saveValue, user)) {
+    // oldValue now runs the new code, and keeps its identity and
+    // closure.  Give it the properties arranged on saveValue by the
+    // metadata directives, except those describing the code itself.
+    var skip = ['length', 'name', 'prototype'].concat($.utils.code.editDataKeys);
+    var keys = Object.getOwnPropertyNames(oldValue)
+        .concat(Object.getOwnPropertyNames(saveValue));
+    for (var i = 0; i < keys.length; i++) {
+      var k = keys[i];
+      if (skip.indexOf(k) !== -1) continue;
+      var pd = Object.getOwnPropertyDescriptor(saveValue, k);
+      if (pd) {
+        Object.defineProperty(oldValue, k, pd);
+      } else {
+        delete oldValue[k];
+      }
+    }
+    saveValue = oldValue;
+  } else {
+    // Record last modification data on functions.
+    $.utils.code.recordEdit(oldValue, saveValue, user);
   }
   try {
     binding.set(saveValue);
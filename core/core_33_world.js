@@ -325,9 +325,17 @@ Object.setOwnerOf($.user.grep.search, $.physicals.Maximilian);
 $.user.readMemo = function readMemo(memo) {
   // See $.physical.readMemo for documentation.
   $.physical.readMemo.call(this, memo);
-  if (!this.connection) return;
+  if (!this.connection && !this.isReconnectable()) return;
   memo = $.utils.replacePhysicalsWithName(memo);
   var json = JSON.stringify(memo) + '\n';
+  if (!this.connection) {
+    // Recently disconnected.  Keep output until they reconnect.
+    if (!this.hasOwnProperty('pendingOutput_')) this.pendingOutput_ = [];
+    if (this.pendingOutput_.length < $.user.PENDING_OUTPUT_LIMIT) {
+      this.pendingOutput_.push(json);
+    }
+    return;
+  }
   try {
     this.connection.write(json);
   } catch(e) {
@@ -424,16 +432,46 @@ $.user.onConnect = function onConnect(reconnect) {
 };
 Object.setOwnerOf($.user.onConnect, $.physicals.Maximilian);
 Object.setOwnerOf($.user.onConnect.prototype, $.physicals.Maximilian);
+$.user.isReconnectable = function isReconnectable() {
+  /* Returns true iff this user's connection dropped recently enough
+   * that a new connection should be treated as a reconnection,
+   * receiving any output sent in the interim.
+   */
+  return Boolean(this.disconnectTime) &&
+      Date.now() - this.disconnectTime < $.user.RECONNECT_GRACE_MS;
+};
+Object.setOwnerOf($.user.isReconnectable, $.physicals.Maximilian);
+$.user.flushPendingOutput = function flushPendingOutput() {
+  /* Called from $.servers.telnet.connection.onReceiveLine once a new
+   * connection is logged in to this user.  Send any output which was
+   * buffered by readMemo while the user was disconnected, provided
+   * they have reconnected within the grace period.
+   */
+  var pending = this.pendingOutput_;
+  var reconnectable = this.isReconnectable();
+  delete this.pendingOutput_;
+  delete this.disconnectTime;
+  if (!pending || !reconnectable || !this.connection) return;
+  for (var i = 0; i < pending.length; i++) {
+    this.connection.write(pending[i]);
+  }
+};
+Object.setOwnerOf($.user.flushPendingOutput, $.physicals.Maximilian);
+$.user.RECONNECT_GRACE_MS = 60000;
+$.user.PENDING_OUTPUT_LIMIT = 100;
 $.user.onDisconnect = function onDisconnect() {
   /* Called from $.servers.telnet.connection.onEnd once connection
    * has dropped.
    */
-  // Have they made an effort to not look like a guest?
-  if (this.hasOwnProperty('description') ||
+  // Have they made an effort to not look like a guest?  (Guest users
+  // created by $.servers.telnet.createGuest may yet reconnect; they
+  // will be destroyed by $.servers.telnet.expireGuest if they don't.)
+  if (this.isGuest ||
+      this.hasOwnProperty('description') ||
       this.hasOwnProperty('home') ||
       !this.name.match(/^Guest(?: #\d+)?/) ||
       Object.getOwnPropertyNames(this).length > 5) {
-    // Not a guest.
+    // Not a guest, or not yet.
     if (this.location) {
       this.location.narrate(String(this) + ' nods off to sleep.', this);
     }
@@ -27,6 +27,7 @@ $.servers.telnet = {};
 $.servers.telnet.connection = (new 'Object.create')($.connection);
 $.servers.telnet.connection.onReceiveLine = function onReceiveLine(text) {
   if (this.user) {  // Logged in?
+    if (this.refuseForMaintenance(this.user)) return;
     // Set 'user' for this thread, and permissions for call
     Object.setOwnerOf(Thread.current(), this.user);
     setPerms(this.user);
@@ -36,18 +37,28 @@ $.servers.telnet.connection.onReceiveLine = function onReceiveLine(text) {
   // Remainder of function handles login.
   // TODO(fraser): Make sure that no security issues exist due to
   // called code suspending or timing out unexpectedly.
-  var m = text.match(/identify as ([0-9a-f]+)/);
+  var m = text.match(/identify as ([0-9a-f]+|guest)/);
   if (!m) {
     this.write('{type: "narrate", text: "Unknown command: ' +
                $.utils.html.preserveWhitespace(text) + '"}');
   }
-  var user = $.userDatabase.get(m[1]);
-  if (!user) {
-    user = $.servers.telnet.createUser();
-    $.userDatabase.set(m[1], user);
+  var user;
+  if (m[1] === 'guest') {
+    if (this.refuseForMaintenance(undefined)) return;
+    // Unauthenticated connection: make an ephemeral user which is not
+    // recorded in the user database.
+    user = $.servers.telnet.createGuest();
+  } else {
+    user = $.userDatabase.get(m[1]);
+    if (this.refuseForMaintenance(user)) return;
+    if (!user) {
+      user = $.servers.telnet.createUser();
+      $.userDatabase.set(m[1], user);
+    }
   }
   this.user = user;
-  var rebind = false;
+  // Treat a connection soon after a dropped one as a reconnection.
+  var rebind = user.isReconnectable();
   if (user.connection) {
     rebind = true;
     try {
@@ -62,9 +73,27 @@ $.servers.telnet.connection.onReceiveLine = function onReceiveLine(text) {
   user.connection = this;
   Object.setOwnerOf(Thread.current(), user);
   setPerms(this.user);
+  user.flushPendingOutput();
   new Thread(user.onConnect, 0, user, rebind);
 };
 Object.setOwnerOf($.servers.telnet.connection.onReceiveLine, $.physicals.Maximilian);
+$.servers.telnet.connection.refuseForMaintenance = function refuseForMaintenance(user) {
+  /* If the server is in maintenance mode, and user (undefined if not
+   * yet logged in) is not listed in $.system.admins, tell the
+   * connection why its input won't be acted upon and return true.
+   * Admins can log in and run commands as usual, so they can end
+   * maintenance mode (e.g., with ;$.system.setMaintenance(null)).
+   */
+  var maintenance = $.system.getMaintenance();
+  if (maintenance === null ||
+      (user && $.system.admins.indexOf(user) !== -1)) {
+    return false;
+  }
+  // Commands won't be run until maintenance is over; explain why.
+  this.write(JSON.stringify({type: 'narrate', text: maintenance}) + '\n');
+  return true;
+};
+Object.setOwnerOf($.servers.telnet.connection.refuseForMaintenance, $.physicals.Maximilian);
 $.servers.telnet.connection.onEnd = function onEnd() {
   var user = this.user;
   // Mark connection as closed.
@@ -74,11 +103,16 @@ $.servers.telnet.connection.onEnd = function onEnd() {
     this.user = null;
     if (user.connection === this) {
       user.connection = null;
+      user.disconnectTime = Date.now();
       $.system.log('Unbinding connection from ' + user.name);
       (function () {
         setPerms(user);
         new Thread(user.onDisconnect, 0, user);
       })();
+      if (user.isGuest) {
+        setTimeout($.servers.telnet.expireGuest.bind(null, user),
+                   $.servers.telnet.GUEST_TIMEOUT_MS);
+      }
     }
   }
   // Remove this and any other closed / debound connections from array of open connections.
@@ -112,6 +146,27 @@ $.servers.telnet.createUser = function createUser() {
   return guest;
 };
 Object.setOwnerOf($.servers.telnet.createUser, $.physicals.Maximilian);
+$.servers.telnet.createGuest = function createGuest() {
+  /* Create the ephemeral user for a connection which has not logged
+   * in.  The user will be destroyed by expireGuest once it has been
+   * disconnected for GUEST_TIMEOUT_MS.
+   */
+  var guest = $.servers.telnet.createUser();
+  guest.isGuest = true;
+  return guest;
+};
+Object.setOwnerOf($.servers.telnet.createGuest, $.physicals.Maximilian);
+$.servers.telnet.expireGuest = function expireGuest(guest) {
+  /* Destroy guest, unless it has been reconnected (or already destroyed)
+   * in the meantime.
+   */
+  if (!$.user.isPrototypeOf(guest) || guest.connection) return;
+  if (guest.location) {
+    guest.location.narrate(String(guest) + ' suddenly vanishes without a trace!');
+  }
+  guest.destroy();
+};
+Object.setOwnerOf($.servers.telnet.expireGuest, $.physicals.Maximilian);
 $.servers.telnet.validate = function validate() {
   // Examine supposedly-open connections and close and/or remove
   // closed / timed-out / debound ones from the .connected arary.
@@ -137,6 +192,7 @@ $.servers.telnet.validate = function validate() {
 Object.setOwnerOf($.servers.telnet.validate, $.physicals.Maximilian);
 Object.setOwnerOf($.servers.telnet.validate.prototype, $.physicals.Maximilian);
 $.servers.telnet.LOGIN_TIMEOUT_MS = 20000;
+$.servers.telnet.GUEST_TIMEOUT_MS = 300000;
 
 $.servers.telnet.connected = [];
 
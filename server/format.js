@@ -0,0 +1,151 @@
+/**
+ * @license
+ * Copyright 2020 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/**
+ * @fileoverview A simple formatter for JavaScript code, which
+ *     normalises indentation (and trailing whitespace) while
+ *     preserving line breaks, comments and everything else.
+ * @author cpcallen@google.com (Christopher Allen)
+ */
+'use strict';
+
+var Parser = require('./parser').Parser;
+
+/**
+ * Tokens which, when they end a line, do not cause the next line to
+ * be treated as a continuation of the same statement.
+ * @const {!Set<string>}
+ */
+var NO_CONTINUATION = new Set([';', '{', '}', '(', '[', ',', ':']);
+
+/**
+ * Re-indent JavaScript source code.  Each line is indented according
+ * to the brackets enclosing it: one level for each line on which
+ * still-open brackets were opened, plus one level for the bodies of
+ * switch cases and two levels for continuation lines (of statements
+ * broken across lines, or wrapped inside parentheses).  Lines within
+ * multi-line comments keep their position relative to the start of
+ * the comment.
+ *
+ * The code is tokenised but not parsed, so syntax errors other than
+ * invalid tokens (e.g. unterminated strings) are not detected.
+ * @param {string} src Source code.
+ * @param {number|string=} indent Number of spaces or string to use
+ *     for each level of indentation.  Defaults to 2 spaces.
+ * @return {string} Re-indented source code.
+ * @throws {SyntaxError} If src cannot be tokenised.
+ */
+var format = function(src, indent) {
+  if (indent === undefined) indent = 2;
+  if (typeof indent === 'number') indent = ' '.repeat(indent);
+
+  var tokens = [];
+  var comments = [];
+  var options = {
+    ecmaVersion: 5,
+    onComment: function(block, text, start, end) {
+      comments.push({start: start, end: end});
+    },
+  };
+  for (var token of Parser.tokenizer(src, options)) {
+    tokens.push(token);
+  }
+
+  var lines = src.split('\n');
+  var out = [];
+  // Stack of open brackets, each recording the indentation level of
+  // the line it was opened on, whether it is a parenthesis and
+  // whether a case label has been seen directly inside it.
+  var stack = [];
+  var t = 0;  // Index of next unprocessed token.
+  var c = 0;  // Index of first comment not ending before current line.
+  var lastToken = null;  // Last token on a previous line.
+  var level = 0;  // Level of most recent line containing tokens.
+  var commentLevel = 0;  // Level of line on which current comment began.
+  var commentIndent = 0;  // Original indentation of that line.
+  var pos = 0;  // Offset of start of current line.
+  for (var i = 0; i < lines.length; pos += lines[i].length + 1, i++) {
+    var line = lines[i].replace(/\s+$/, '');
+    var trimmed = line.trim();
+    var originalIndent = line.length - trimmed.length;
+    // Process tokens from previous lines.
+    for (; t < tokens.length && tokens[t].end <= pos; t++) {
+      var label = tokens[t].type.label;
+      if (label === '{' || label === '(' || label === '[') {
+        stack.push({level: level, paren: label === '(', inCase: false});
+      } else if (label === '}' || label === ')' || label === ']') {
+        // Brackets opened later on the same line as a closing bracket
+        // (e.g. '{' in "    b) {") belong at the level of the line
+        // where the closed bracket was opened.
+        var closed = stack.pop();
+        if (closed) level = closed.level;
+      }
+      lastToken = tokens[t];
+    }
+    // Lines starting inside a multi-line token are left untouched.
+    if (t < tokens.length && tokens[t].start < pos) {
+      out.push(line);
+      continue;
+    }
+    // Lines starting inside a multi-line comment.
+    while (c < comments.length && comments[c].end <= pos) c++;
+    if (c < comments.length && comments[c].start < pos) {
+      out.push(trimmed ? indent.repeat(commentLevel) +
+          ' '.repeat(Math.max(0, originalIndent - commentIndent)) + trimmed :
+          '');
+      continue;
+    }
+    if (!trimmed) {
+      out.push('');
+      continue;
+    }
+    var top = stack[stack.length - 1];
+    var first = (t < tokens.length && tokens[t].start < pos + line.length) ?
+        tokens[t] : null;
+    var label = first ? first.type.label : '';
+    var lineLevel;
+    if (label === '}' || label === ')' || label === ']') {
+      lineLevel = top ? top.level : 0;
+    } else if (label === 'case' || label === 'default') {
+      lineLevel = top ? top.level + 1 : 0;
+      if (top) top.inCase = true;
+    } else if (top && top.paren) {
+      // Google style: wrapped arguments, conditions, etc. get a
+      // continuation indent.
+      lineLevel = top.level + 2;
+    } else {
+      lineLevel = top ? top.level + 1 : 0;
+      if (top && top.inCase) lineLevel++;
+      if (first && lastToken && !NO_CONTINUATION.has(lastToken.type.label)) {
+        lineLevel += 2;
+      }
+    }
+    if (first) level = lineLevel;
+    // Remember level in case a comment starting on this line
+    // continues onto following lines.
+    commentLevel = lineLevel;
+    commentIndent = originalIndent;
+    out.push(indent.repeat(lineLevel) + trimmed);
+  }
+  return out.join('\n');
+};
+
+///////////////////////////////////////////////////////////////////////////////
+// Exports
+///////////////////////////////////////////////////////////////////////////////
+
+exports.format = format;
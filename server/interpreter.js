@@ -21,7 +21,10 @@
  */
 'use strict';
 
+var format = require('./format').format;
 var IterableWeakMap = require('./iterable_weakmap');
+var IterableWeakSet = require('./iterable_weakset');
+var lint = require('./lint').lint;
 var net = require('net');
 var http = require('http');
 var https = require('https');
@@ -35,10 +38,11 @@ var Parser = parser.Parser;
  * Version number for the serialisation format.  MUST be incremented
  * when any change is made to the implementation of Interpreter and
  * related classes (in this file and others) which would change how
- * the runtime state is represented on disk.
+ * the runtime state is represented on disk; see Serializer.migrations
+ * (in serialize.js).
  * @type {number}
  */
-var SERIALIZATION_VERSION = 1;
+var SERIALIZATION_VERSION = 7;
 
 /**
  * Create a new interpreter.
@@ -74,6 +78,39 @@ var Interpreter = function(options) {
    * @private @const {!Set<!Interpreter.prototype.Object>}
    */
   this.toStringVisited_ = new Set;
+  /**
+   * Property change observers.  Maps each observed object to a map
+   * of observer functions to the perms each was registered with.
+   * (Must exist before builtins are created.)
+   * @private @const {!IterableWeakMap<!Interpreter.prototype.Object,
+   *     !Map<!Interpreter.prototype.Function, !Interpreter.Owner>>}
+   */
+  this.observers_ = new IterableWeakMap;
+  /**
+   * Property change observers registered by the embedder (see
+   * .observe).  Maps each observed object to a set of callbacks.
+   * (Not serialized.)
+   * @private @const {!IterableWeakMap<!Interpreter.prototype.Object,
+   *     !Set<!Interpreter.ObserverCallback>>}
+   */
+  this.hostObservers_ = new IterableWeakMap;
+  /**
+   * Objects frozen by .freezeBuiltins.
+   * @private @const {!IterableWeakSet<!Interpreter.prototype.Object>}
+   */
+  this.frozen_ = new IterableWeakSet;
+  /**
+   * Objects that may not be modified, if in read-only mode (see
+   * .makeReadOnly); otherwise null.  (Not serialized.)
+   * @private @type {?WeakSet<!Interpreter.prototype.Object>}
+   */
+  this.readOnly_ = null;
+  /**
+   * Keys of properties which may still be set, defined or deleted on
+   * read-only objects (see .makeReadOnly).  (Not serialized.)
+   * @private @type {!Set<string>}
+   */
+  this.readOnlyWritableKeys_ = new Set;
 
   /**
    * The interpreter's global scope.
@@ -92,6 +129,26 @@ var Interpreter = function(options) {
   this.threadTimeLimit_ = undefined;
   /** @private (Type is whatever is returned by setTimeout()) */
   this.runner_ = null;
+  /**
+   * Publish/subscribe topics.  Maps each topic to a map of subscribed
+   * functions to the perms each was subscribed with.
+   * @private @const {!Map<string, !Map<!Interpreter.prototype.Function,
+   *                                     !Interpreter.Owner>>}
+   */
+  this.subscriptions_ = new Map;
+  /**
+   * If not null, the interpreter is in maintenance mode: only system
+   * threads will be scheduled, and this is the message to show to
+   * users in the meantime.  See .setMaintenance.
+   * @type {?string}
+   */
+  this.maintenanceMessage = null;
+  /**
+   * Most recent slow tasks, oldest first.  See .checkSlowTask_.
+   * (Not serialized.)
+   * @private @const {!Array<!Interpreter.SlowTask>}
+   */
+  this.slowTasks_ = [];
   /** @type {boolean} */
   this.done = true;  // True if no non-ZOMBIE threads exist.
 
@@ -138,6 +195,17 @@ Interpreter.prototype.now = function() {
   return this.uptime() + this.previousTime_;
 };
 
+/**
+ * Return the current wall-clock time, in milliseconds since the epoch.
+ * This is the clock seen by userland code via Date.now(), new Date()
+ * and Date(); embedders (e.g., tests or replay tools) that need to
+ * control the time seen by user code can override it.
+ * @return {number} Current time in milliseconds since the epoch.
+ */
+Interpreter.prototype.wallTime = function() {
+  return Date.now();
+};
+
 /**
  * Create a new thread and add it to .threads_, and create a companion
  * user-visible wrapper object and return it.
@@ -164,14 +232,17 @@ Interpreter.prototype.createThread = function(owner, state, runAt, timeLimit) {
  * the global scope has.
  * @param {string} src JavaScript source code to parse and run.
  * @param {number=} timeLimit Maximum runtime without suspending (in ms).
+ * @param {string=} filename Name of file src was loaded from, if any.
+ *     Used in place of the source text in stack traces.
  * @return {!Interpreter.prototype.Thread} Userland Thread object.
  */
-Interpreter.prototype.createThreadForSrc = function(src, timeLimit) {
+Interpreter.prototype.createThreadForSrc = function(src, timeLimit, filename) {
   if (typeof src !== 'string') throw new TypeError('src must be a string');
   if (this.options.trimProgram) {
     src = src.trim();
   }
   var ast = this.compile_(src);
+  if (filename !== undefined) ast['filename'] = String(filename);
   this.populateScope_(ast, this.global);
   var state = new Interpreter.State(ast, this.global);
   return this.createThread(this.ROOT, state, undefined, timeLimit);
@@ -195,6 +266,46 @@ Interpreter.prototype.createThreadForFuncCall = function(
   return this.createThread(owner, state, runAt, timeLimit);
 };
 
+/**
+ * Create a new thread to call a method on a server or connection
+ * object in response to a network event.  Such threads are owned by
+ * the server's owner and continue to be scheduled in maintenance mode
+ * (so that connections can be told what is going on, and so that
+ * maintenance mode can be ended via e.g. the eval server).
+ * @private
+ * @param {!Interpreter.prototype.Server} server Server the event is for.
+ * @param {!Interpreter.prototype.Function} func Function to call.
+ * @param {?Interpreter.Value} thisVal value of 'this' in function call.
+ * @param {!Array<?Interpreter.Value>} args Arguments to pass.
+ * @return {!Interpreter.prototype.Thread} Userland Thread object.
+ */
+Interpreter.prototype.createThreadForNetwork_ = function(
+    server, func, thisVal, args) {
+  var wrapper = this.createThreadForFuncCall(
+      /** @type {!Interpreter.Owner} */(server.owner), func, thisVal, args,
+      undefined, server.timeLimit);
+  wrapper.thread.network = true;
+  return wrapper;
+};
+
+/**
+ * Kill the thread with the given id, whatever its status.  The thread
+ * will not execute any further steps and will be removed by the next
+ * call to .schedule().  Used by the Thread.kill native, and available
+ * to the host (e.g. to kill a runaway or BLOCKED thread reported by
+ * CC.blockedThreads without restarting the server).
+ * @param {number} id The id of the thread to kill.
+ * @return {boolean} True iff a live thread with that id was found.
+ */
+Interpreter.prototype.killThread = function(id) {
+  var thread = this.threads_[id];
+  if (!thread || thread.status === Interpreter.Thread.Status.ZOMBIE) {
+    return false;
+  }
+  thread.status = Interpreter.Thread.Status.ZOMBIE;
+  return true;
+};
+
 /**
  * Schedule the next runnable thread.  Returns 0 if a READY thread
  * successfuly scheduled (or if the current thread was already
@@ -228,8 +339,13 @@ Interpreter.prototype.schedule = function() {
         delete threads[i];
         continue;
       case Interpreter.Thread.Status.BLOCKED:
-        // Ignore blocked threads except noting existence.
+        // Ignore blocked threads except noting existence (and
+        // reporting any which have been waiting too long).
         this.done = false;
+        if (this.options.blockedThreadMs && !threads[i].blockedReported &&
+            now - threads[i].blockedSince >= this.options.blockedThreadMs) {
+          this.reportBlockedThread_(threads[i], now);
+        }
         continue;
       case Interpreter.Thread.Status.SLEEPING:
         if (threads[i].runAt > now) {
@@ -241,6 +357,12 @@ Interpreter.prototype.schedule = function() {
         threads[i].status = Interpreter.Thread.Status.READY;
         // fall through
       case Interpreter.Thread.Status.READY:
+        // In maintenance mode, user threads must wait until it ends.
+        if (this.maintenanceMessage !== null &&
+            !this.isSystemThread_(threads[i])) {
+          this.done = false;
+          continue;
+        }
         // Is this this most-overdue thread found so far?
         if (threads[i].runAt < runAt) {
           this.thread_ = threads[i];
@@ -257,6 +379,253 @@ Interpreter.prototype.schedule = function() {
   return runAt < now ? 0 : runAt;
 };
 
+/**
+ * Log a thread which has been BLOCKED for longer than the
+ * .blockedThreadMs option, along with its stack.  Each blocked thread
+ * is reported at most once per blocking call.
+ * @private
+ * @param {!Interpreter.Thread} thread The blocked thread.
+ * @param {number} now The current time (as returned by .now()).
+ */
+Interpreter.prototype.reportBlockedThread_ = function(thread, now) {
+  thread.blockedReported = true;
+  this.log('blocked', 'Thread %d has been blocked for %d ms',
+      thread.id, now - thread.blockedSince);
+  this.log('blocked', this.formatCallers(thread.callers(this.ROOT), this.ROOT));
+};
+
+/**
+ * Is thread a system thread (i.e., one owned by root, or one handling
+ * a network event), which should continue to be scheduled in
+ * maintenance mode?
+ * @private
+ * @param {!Interpreter.Thread} thread The thread to check.
+ * @return {boolean} True iff thread is a system thread.
+ */
+Interpreter.prototype.isSystemThread_ = function(thread) {
+  return !thread.wrapper || thread.network ||
+      thread.wrapper.owner === this.ROOT;
+};
+
+/**
+ * Enter or leave maintenance mode.  While in maintenance mode, only
+ * system threads (those owned by root, or handling network events)
+ * are scheduled; user threads wait, and resume where they left off
+ * once maintenance mode is left.
+ * @param {?string} message Message to show to users while in
+ *     maintenance mode, or null to leave maintenance mode.
+ */
+Interpreter.prototype.setMaintenance = function(message) {
+  this.maintenanceMessage = message;
+  // Wake any user threads that were waiting.
+  if (message === null) this.go_();
+};
+
+/**
+ * Names of the global variables defined by the startup files (in
+ * startup/*.js), whose values are treated as intrinsics by
+ * .freezeBuiltins even when they are not builtins (e.g. Map and
+ * Promise, which are polyfills, or Math, which is an ordinary object).
+ * @const {!Array<string>}
+ */
+Interpreter.STARTUP_GLOBALS = [
+  // es5.js:
+  'Object', 'Function', 'Array', 'String', 'Boolean', 'Number', 'Date',
+  'RegExp', 'Error', 'EvalError', 'RangeError', 'ReferenceError',
+  'SyntaxError', 'TypeError', 'URIError', 'Math', 'JSON',
+  'parseInt', 'parseFloat', 'isNaN', 'isFinite', 'escape', 'unescape',
+  'decodeURI', 'decodeURIComponent', 'encodeURI', 'encodeURIComponent',
+  // es6.js:
+  'WeakMap', 'Map', 'Set', 'WeakSet',
+  // esx.js:
+  'Thread', 'PermissionError', 'suspend', 'setTimeout', 'clearTimeout',
+  'setInterval', 'clearInterval', 'Promise',
+  // cc.js:
+  'CC', 'perms', 'setPerms',
+];
+
+/**
+ * Freeze every builtin object (constructors, prototypes, native
+ * functions, etc.) and every intrinsic defined by the startup files
+ * (see Interpreter.STARTUP_GLOBALS), along with the methods and
+ * prototypes installed on them (e.g. polyfills such as
+ * Array.prototype.find and Map.prototype.get), so that user code
+ * cannot add, modify or delete their properties (e.g. to poison
+ * Array.prototype for other users).  Must not be called until
+ * startup code has finished installing polyfills.  N.B.: since
+ * objects are frozen in place, they remain frozen in any subsequent
+ * checkpoint.
+ */
+Interpreter.prototype.freezeBuiltins = function() {
+  var intrp = this;
+  var frozen = new Set();
+  /**
+   * Freeze obj, plus any functions (and their prototypes) that are
+   * values of its own properties.
+   * @param {?Interpreter.Value} obj Object to freeze.
+   */
+  function freeze(obj) {
+    if (!(obj instanceof intrp.Object) || frozen.has(obj)) return;
+    frozen.add(obj);
+    intrp.frozen_.add(obj);
+    var keys = Object.getOwnPropertyNames(obj.properties);
+    for (var i = 0; i < keys.length; i++) {
+      var value = obj.properties[keys[i]];
+      if (value instanceof intrp.Function ||
+          (keys[i] === 'prototype' && obj instanceof intrp.Function)) {
+        freeze(value);
+      }
+    }
+  }
+  var entries = this.builtins.entries();
+  for (var i = 0; i < entries.length; i++) {
+    freeze(entries[i][1]);
+  }
+  for (var i = 0; i < Interpreter.STARTUP_GLOBALS.length; i++) {
+    var name = Interpreter.STARTUP_GLOBALS[i];
+    if (this.global.hasBinding(name)) {
+      freeze(this.global.get(name));
+    }
+  }
+};
+
+/**
+ * Enter read-only mode: make each of the given objects immutable, so
+ * that any attempt to set, define or delete one of its properties,
+ * change its prototype or prevent extensions on it fails with a
+ * TypeError.  Other objects (including any created later, even if
+ * they inherit from a read-only object) are unaffected.  Used to
+ * safely inspect a copy of a production database.
+ *
+ * Natives with effects outside the interpreter that a copy of a
+ * production database should not repeat (listening on new ports and
+ * making HTTP requests) are disabled in read-only mode.
+ * @param {!Array<!Interpreter.prototype.Object>} objects Objects to
+ *     make read-only.
+ * @param {!Array<string>=} writableKeys Keys of properties which
+ *     may nevertheless be set, defined or deleted on any of objects
+ *     (e.g. ones recording per-session state on users).
+ */
+Interpreter.prototype.makeReadOnly = function(objects, writableKeys) {
+  if (!this.readOnly_) {
+    this.readOnly_ = new WeakSet;
+  }
+  for (var i = 0; i < objects.length; i++) {
+    this.readOnly_.add(objects[i]);
+  }
+  if (writableKeys) {
+    for (i = 0; i < writableKeys.length; i++) {
+      this.readOnlyWritableKeys_.add(writableKeys[i]);
+    }
+  }
+};
+
+/**
+ * Throw a TypeError if obj has been made read-only, unless key is
+ * given and is one of the keys allowed to be modified regardless.
+ * @private
+ * @param {!Interpreter.prototype.Object} obj The object to be modified.
+ * @param {!Interpreter.Owner} perms Who is trying to modify it?
+ * @param {string=} key Key of the property to be modified, if any.
+ */
+Interpreter.prototype.checkNotReadOnly_ = function(obj, perms, key) {
+  if (this.readOnly_ && this.readOnly_.has(obj) &&
+      (key === undefined || !this.readOnlyWritableKeys_.has(key))) {
+    throw new this.Error(perms, this.TYPE_ERROR,
+        'Cannot modify object: server is in read-only mode');
+  }
+};
+
+/**
+ * Throw a TypeError if obj has been frozen by .freezeBuiltins.
+ * (Objects inheriting from frozen objects are unaffected: unlike
+ * Object.freeze, this does not prevent them from shadowing
+ * properties of the frozen object by assignment.)
+ * @private
+ * @param {!Interpreter.prototype.Object} obj The object to be modified.
+ * @param {!Interpreter.Owner} perms Who is trying to modify it?
+ */
+Interpreter.prototype.checkNotFrozen_ = function(obj, perms) {
+  if (this.frozen_.has(obj)) {
+    throw new this.Error(perms, this.TYPE_ERROR,
+        'Cannot modify frozen builtin object');
+  }
+};
+
+/**
+ * Throw a TypeError if the server is in read-only mode.  Called by
+ * natives with effects outside the interpreter; see .makeReadOnly.
+ * @private
+ * @param {!Interpreter.Owner} perms Who is trying to call the native?
+ * @param {string} name Name of the native.
+ */
+Interpreter.prototype.checkNotReadOnlyMode_ = function(perms, name) {
+  if (this.readOnly_) {
+    throw new this.Error(perms, this.TYPE_ERROR,
+        name + ' is disabled: server is in read-only mode');
+  }
+};
+
+/**
+ * Register a callback to be called (synchronously) whenever a
+ * property of obj is set, defined or deleted.  Callbacks are not
+ * preserved across serialization.
+ * @param {!Interpreter.prototype.Object} obj The object to observe.
+ * @param {!Interpreter.ObserverCallback} callback The callback.
+ */
+Interpreter.prototype.observe = function(obj, callback) {
+  var callbacks = this.hostObservers_.get(obj);
+  if (!callbacks) {
+    callbacks = new Set;
+    this.hostObservers_.set(obj, callbacks);
+  }
+  callbacks.add(callback);
+};
+
+/**
+ * Unregister a callback previously registered with .observe.
+ * @param {!Interpreter.prototype.Object} obj The observed object.
+ * @param {!Interpreter.ObserverCallback} callback The callback.
+ * @return {boolean} True iff callback was registered on obj.
+ */
+Interpreter.prototype.unobserve = function(obj, callback) {
+  var callbacks = this.hostObservers_.get(obj);
+  if (!callbacks || !callbacks.delete(callback)) {
+    return false;
+  }
+  if (!callbacks.size) {
+    this.hostObservers_.delete(obj);
+  }
+  return true;
+};
+
+/**
+ * Notify observers of obj that one of its properties has changed:
+ * embedder callbacks are called immediately, while userland observer
+ * functions are each called in a new thread.
+ * @private
+ * @param {!Interpreter.prototype.Object} obj The changed object.
+ * @param {string} key The key of the changed property.
+ * @param {string} type Type of change: 'set' or 'delete'.
+ */
+Interpreter.prototype.notifyObservers_ = function(obj, key, type) {
+  var callbacks = this.hostObservers_.get(obj);
+  if (callbacks) {
+    callbacks.forEach(function(callback) {
+      callback(obj, key, type);
+    });
+  }
+  var observers = this.observers_.get(obj);
+  if (observers) {
+    var intrp = this;
+    var timeLimit = this.thread_ ? this.thread_.timeLimit : undefined;
+    observers.forEach(function(owner, func) {
+      intrp.createThreadForFuncCall(owner, func, undefined, [obj, key, type],
+          undefined, timeLimit);
+    });
+  }
+};
+
 /**
  * Execute one step of the interpreter.  Schedules the next runnable
  * thread if required.
@@ -304,11 +673,19 @@ Interpreter.prototype.run = function() {
     throw new Error("Can't run stopped interpreter");
   }
   var t;
+  var checkSlow = Boolean(this.options.slowTaskMs ||
+      this.options.slowTaskSteps);
   while ((t = this.schedule()) === 0) {
     var thread = this.thread_;
     var stack = thread.stateStack_;
+    var start = checkSlow ? this.now() : 0;
+    var steps = 0;
+    var reported = !checkSlow;
     while (thread.status === Interpreter.Thread.Status.READY) {
       this.step_(thread, stack);
+      if (!reported && ++steps % Interpreter.SLOW_TASK_CHECK_STEPS === 0) {
+        reported = this.checkSlowTask_(thread, start, steps);
+      }
     }
   }
   if (t === Number.MAX_VALUE) {
@@ -433,9 +810,8 @@ Interpreter.prototype.pause = function() {
           // for this thread?  Note that this will typically be root,
           // and .onError will therefore get caller perms === root,
           // which is probably dangerous.
-          intrp.createThreadForFuncCall(
-              server.owner, func, server.proto, [userError],
-              undefined, server.timeLimit);
+          intrp.createThreadForNetwork_(
+              server, func, server.proto, [userError]);
         });
       }
       // Reset .uptime() to start counting from *NOW*, and .now() to
@@ -483,7 +859,7 @@ Interpreter.prototype.postDeserialize = function() {
   if (this.serializationVersion !== SERIALIZATION_VERSION) {
     throw new Error('version error: seralized interpreter was version ' +
         this.serializationVersion + '; current version is ' +
-        SERIALIZATION_VERSION);
+        SERIALIZATION_VERSION + ' (use the migrate tool to upgrade it)');
   }
   // Checkpointed interpreter was probably paused, but because we're
   // restoring from a checkpoint the resurrected interpreter is
@@ -618,10 +994,10 @@ Interpreter.prototype.initBuiltins_ = function() {
   // created with createMutableBinding.
   this.global.createMutableBinding('eval', eval_);
 
-  this.createNativeFunction('isFinite', isFinite, false);
-  this.createNativeFunction('isNaN', isNaN, false);
-  this.createNativeFunction('parseFloat', parseFloat, false);
-  this.createNativeFunction('parseInt', parseInt, false);
+  this.createNativeFunction('isFinite', isFinite, false, ['number']);
+  this.createNativeFunction('isNaN', isNaN, false, ['number']);
+  this.createNativeFunction('parseFloat', parseFloat, false, ['string']);
+  this.createNativeFunction('parseInt', parseInt, false, ['string', 'number']);
 
   var strFunctions = [
     [escape, 'escape'], [unescape, 'unescape'],
@@ -639,11 +1015,16 @@ Interpreter.prototype.initBuiltins_ = function() {
         }
       };
     })(strFunctions[i][0]);
-    this.createNativeFunction(strFunctions[i][1], wrapper, false);
+    this.createNativeFunction(strFunctions[i][1], wrapper, false, ['string']);
   }
 
   // Initialize CC-specific globals.
   this.initThread_();
+  this.initPubSub_();
+  this.initObserve_();
+  this.initEncoding_();
+  this.initMaintenance_();
+  this.initCodeTools_();
   this.initNetwork_();
 };
 
@@ -701,7 +1082,12 @@ Interpreter.prototype.initObject_ = function() {
     call: function(intrp, thread, state, thisVal, args) {
       var perms = state.scope.perms;
       var obj = intrp.toObject(args[0], perms);
-      return intrp.createArrayFromList(obj.ownKeys(perms), perms);
+      // Only enumerable properties.  ES5.1 §15.2.3.14.
+      var keys = obj.ownKeys(perms).filter(function(key) {
+        var pd = obj.getOwnPropertyDescriptor(key, perms);
+        return pd && pd.enumerable;
+      });
+      return intrp.createArrayFromList(keys, perms);
     }
   });
 
@@ -988,8 +1374,13 @@ Interpreter.prototype.initFunction_ = function() {
       var info = state.info_;
       info.func = func;
       info.this = thisArg;
-      info.args = argList;
+      info.arguments = argList;
       info.construct = false;
+      info.toPrimitive = null;
+      // Let the Call step convert arguments to primitives if required.
+      if (intrp.argHints_(func, false)) {
+        return Interpreter.FunctionResult.CallAgain;
+      }
       // But just go and do the first .call directly.
       return func.call(intrp, thread, state, thisArg, argList);
     }
@@ -1042,8 +1433,13 @@ Interpreter.prototype.initFunction_ = function() {
       var info = state.info_;
       info.func = func;
       info.this = thisArg;
-      info.args = argList;
+      info.arguments = argList;
       info.construct = false;
+      info.toPrimitive = null;
+      // Let the Call step convert arguments to primitives if required.
+      if (intrp.argHints_(func, false)) {
+        return Interpreter.FunctionResult.CallAgain;
+      }
       // But just go and do the first .call directly.
       return func.call(intrp, thread, state, thisArg, argList);
     }
@@ -1154,6 +1550,7 @@ Interpreter.prototype.initArray_ = function() {
 
   new this.NativeFunction({
     id: 'Array.prototype.includes', length: 1,
+    argHints: [undefined, 'number'],
     /** @type {!Interpreter.NativeCallImpl} */
     call: function(intrp, thread, state, thisVal, args) {
       var searchElement = args[0];
@@ -1181,6 +1578,7 @@ Interpreter.prototype.initArray_ = function() {
 
   new this.NativeFunction({
     id: 'Array.prototype.indexOf', length: 1,
+    argHints: [undefined, 'number'],
     /** @type {!Interpreter.NativeCallImpl} */
     call: function(intrp, thread, state, thisVal, args) {
       var searchElement = args[0];
@@ -1204,6 +1602,7 @@ Interpreter.prototype.initArray_ = function() {
 
   new this.NativeFunction({
     id: 'Array.prototype.lastIndexOf', length: 1,
+    argHints: [undefined, 'number'],
     /** @type {!Interpreter.NativeCallImpl} */
     call: function(intrp, thread, state, thisVal, args) {
       var searchElement = args[0];
@@ -1334,6 +1733,7 @@ Interpreter.prototype.initArray_ = function() {
 
   new this.NativeFunction({
     id: 'Array.prototype.slice', length: 2,
+    argHints: ['number', 'number'],
     /** @type {!Interpreter.NativeCallImpl} */
     call: function(intrp, thread, state, thisVal, args) {
       var start = args[0];
@@ -1364,6 +1764,7 @@ Interpreter.prototype.initArray_ = function() {
 
   new this.NativeFunction({
     id: 'Array.prototype.splice', length: 2,
+    argHints: ['number', 'number'],
     /** @type {!Interpreter.NativeCallImpl} */
     call: function(intrp, thread, state, thisVal, args) {
       var start = args[0];
@@ -1571,17 +1972,23 @@ Interpreter.prototype.initString_ = function() {
   };
 
   // Static methods on String.
-  this.createNativeFunction('String.fromCharCode', String.fromCharCode, false);
+  this.createNativeFunction('String.fromCharCode', String.fromCharCode, false,
+                            'number');
 
   // Properties of the String prototype object.
-  // Methods with exclusively primitive arguments.
-  var functions = ['charAt', 'charCodeAt', 'concat', 'endsWith', 'includes',
-      'indexOf', 'lastIndexOf', 'slice', 'startsWith', 'substr', 'substring',
-      'toLocaleLowerCase', 'toLocaleUpperCase', 'toLowerCase', 'toUpperCase',
-      'trim'];
+  // Methods with exclusively primitive arguments, and the hints with
+  // which object arguments should be converted.
+  var functions = [
+    ['charAt', ['number']], ['charCodeAt', ['number']], ['concat', 'string'],
+    ['endsWith', ['string', 'number']], ['includes', ['string', 'number']],
+    ['indexOf', ['string', 'number']], ['lastIndexOf', ['string', 'number']],
+    ['slice', ['number', 'number']], ['startsWith', ['string', 'number']],
+    ['substr', ['number', 'number']], ['substring', ['number', 'number']],
+    ['toLocaleLowerCase'], ['toLocaleUpperCase'], ['toLowerCase'],
+    ['toUpperCase'], ['trim']];
   for (var i = 0; i < functions.length; i++) {
-    this.createNativeFunction('String.prototype.' + functions[i],
-                              String.prototype[functions[i]], false);
+    this.createNativeFunction('String.prototype.' + functions[i][0],
+        String.prototype[functions[i][0]], false, functions[i][1]);
   }
 
   wrapper = function(compareString /*, locales, options*/) {
@@ -1594,6 +2001,19 @@ Interpreter.prototype.initString_ = function() {
   };
   this.createNativeFunction('String.prototype.localeCompare', wrapper, false);
 
+  /**
+   * Global RegExps have their lastIndex reset to zero by
+   * String.prototype.match and .replace (ES5.1 §15.5.4.10-11).  Since
+   * those are implemented by calling the native method on the wrapped
+   * native regexp, update the pseudo-RegExp's lastIndex to match.
+   * @param {?Interpreter.Value} regexp Argument passed to match/replace.
+   */
+  var resetLastIndex = function(regexp) {
+    if (regexp instanceof intrp.RegExp && regexp.regexp.global) {
+      regexp.set('lastIndex', 0, intrp.thread_.perms());
+    }
+  };
+
   wrapper = function(separator, limit) {
     if (separator instanceof intrp.RegExp) {
       separator = separator.regexp;
@@ -1608,6 +2028,7 @@ Interpreter.prototype.initString_ = function() {
       regexp = regexp.regexp;
     }
     var m = this.match(regexp);
+    resetLastIndex(arguments[0]);
     return m && intrp.createArrayFromList(m, intrp.thread_.perms());
   };
   this.createNativeFunction('String.prototype.match', wrapper, false);
@@ -1625,7 +2046,9 @@ Interpreter.prototype.initString_ = function() {
     if (substr instanceof intrp.RegExp) {
       substr = substr.regexp;
     }
-    return String(this).replace(substr, newSubstr);
+    var result = String(this).replace(substr, newSubstr);
+    resetLastIndex(arguments[0]);
+    return result;
   };
   this.createNativeFunction('String.prototype.replace', wrapper, false);
 
@@ -1637,7 +2060,8 @@ Interpreter.prototype.initString_ = function() {
       throw intrp.errorNativeToPseudo(e, intrp.thread_.perms());
     }
   };
-  this.createNativeFunction('String.prototype.repeat', wrapper, false);
+  this.createNativeFunction('String.prototype.repeat', wrapper, false,
+                            ['number']);
 
   new this.NativeFunction({
     id: 'String.prototype.toString', length: 0,
@@ -1738,10 +2162,52 @@ Interpreter.prototype.initNumber_ = function() {
     id: 'Number', length: 1,
     /** @type {!Interpreter.NativeCallImpl} */
     call: function(intrp, thread, state, thisVal, args) {
-      return Number(args.length ? args[0] : 0);
+      // Number(x) should return ToNumber(x) (ES5.1 §9.3) if x is
+      // primitive, or ToNumber(ToPrimitive(x, hint Number)) if not.
+      var value = args.length > 0 ? args[0] : 0;
+      var perms = state.scope.perms;
+      if (!(value instanceof intrp.Object)) {
+        return Number(value);
+      }
+      var step = Number(state.info_.funcState) || 0;
+      if (step > 0 && !(state.value instanceof intrp.Object)) {
+        // Call of .valueOf or .toString by previous visit returned a
+        // primitive.  Convert to number and return.
+        return Number(state.value);
+      }
+      switch(step) {
+        case 0:  // Try calling valueOf.
+          var method = value.get('valueOf', perms);
+          if (method instanceof intrp.Function) {
+            thread.stateStack_[thread.stateStack_.length] =
+                Interpreter.State.newForCall(method, value, [], perms);
+            state.info_.funcState = 1;
+            return Interpreter.FunctionResult.CallAgain;
+          }
+          // FALL THROUGH
+        case 1:  // valueOf call complete (or skipped); try calling toString.
+          method = value.get('toString', perms);
+          if (method instanceof intrp.Function) {
+            thread.stateStack_[thread.stateStack_.length] =
+                Interpreter.State.newForCall(method, value, [], perms);
+            state.info_.funcState = 2;
+            return Interpreter.FunctionResult.CallAgain;
+          }
+          // FALL THROUGH
+        case 2:  // toString complete (or skipped); throw TypeError.
+          throw new intrp.Error(perms, intrp.TYPE_ERROR,
+             'Cannot convert object to primitive value');
+        default:
+          throw new Error('Invalid funcStep in Number??');
+      }
     },
     /** @type {!Interpreter.NativeConstructImpl} */
     construct: function(intrp, thread, state, args) {
+      // The argument is converted by ToNumber (possibly calling its
+      // .valueOf and/or .toString) before the object would be
+      // created.  ES5.1 §15.7.2.1.
+      var r = this.call(intrp, thread, state, undefined, args);
+      if (r === Interpreter.FunctionResult.CallAgain) return r;
       throw new intrp.Error(state.scope.perms, intrp.TYPE_ERROR,
           'Number objects not supported.');
     }
@@ -1782,7 +2248,8 @@ Interpreter.prototype.initNumber_ = function() {
       throw intrp.errorNativeToPseudo(e, intrp.thread_.perms());
     }
   };
-  this.createNativeFunction('Number.prototype.toExponential', wrapper, false);
+  this.createNativeFunction('Number.prototype.toExponential', wrapper, false,
+                            ['number']);
 
   wrapper = function(digits) {
     try {
@@ -1792,7 +2259,8 @@ Interpreter.prototype.initNumber_ = function() {
       throw intrp.errorNativeToPseudo(e, intrp.thread_.perms());
     }
   };
-  this.createNativeFunction('Number.prototype.toFixed', wrapper, false);
+  this.createNativeFunction('Number.prototype.toFixed', wrapper, false,
+                            ['number']);
 
   wrapper = function(precision) {
     try {
@@ -1802,7 +2270,8 @@ Interpreter.prototype.initNumber_ = function() {
       throw intrp.errorNativeToPseudo(e, intrp.thread_.perms());
     }
   };
-  this.createNativeFunction('Number.prototype.toPrecision', wrapper, false);
+  this.createNativeFunction('Number.prototype.toPrecision', wrapper, false,
+                            ['number']);
 
   wrapper = function(/*locales, options*/) {
     // Messing around with arguments so that function's length is 0.
@@ -1816,6 +2285,7 @@ Interpreter.prototype.initNumber_ = function() {
 
   new this.NativeFunction({
     id: 'Number.prototype.toString', length: 1,
+    argHints: ['number'],
     /** @type {!Interpreter.NativeCallImpl} */
     call: function(intrp, thread, state, thisVal, args) {
       var x = thisNumberValue(
@@ -1857,19 +2327,26 @@ Interpreter.prototype.initDate_ = function() {
     if (!intrp.calledWithNew()) {
       // Called as Date().
       // Calling Date() as a function returns a string, no arguments are heeded.
-      return Date();
+      return String(new Date(intrp.wallTime()));
     }
     // Called as new Date().
-    var args = [null].concat(Array.from(arguments));
-    var date = new (Function.prototype.bind.apply(Date, args));
+    if (arguments.length === 0) {
+      var date = new Date(intrp.wallTime());
+    } else {
+      var args = [null].concat(Array.from(arguments));
+      date = new (Function.prototype.bind.apply(Date, args));
+    }
     return new intrp.Date(date, intrp.thread_.perms());
   };
   this.createNativeFunction('Date', wrapper, true);
 
   // Static methods on Date.
-  this.createNativeFunction('Date.now', Date.now, false);
-  this.createNativeFunction('Date.parse', Date.parse, false);
-  this.createNativeFunction('Date.UTC', Date.UTC, false);
+  wrapper = function() {
+    return intrp.wallTime();
+  };
+  this.createNativeFunction('Date.now', wrapper, false);
+  this.createNativeFunction('Date.parse', Date.parse, false, ['string']);
+  this.createNativeFunction('Date.UTC', Date.UTC, false, 'number');
 
   // Instance methods on Date.
   new this.NativeFunction({
@@ -1896,14 +2373,16 @@ Interpreter.prototype.initDate_ = function() {
       'setUTCFullYear', 'setUTCHours', 'setUTCMilliseconds', 'setUTCMinutes',
       'setUTCMonth', 'setUTCSeconds', 'setYear',
       'toDateString', 'toISOString', 'toJSON', 'toGMTString',
-      'toTimeString', 'toUTCString'];
+      'toTimeString', 'toUTCString', 'valueOf'];
   for (var i = 0; i < functions.length; i++) {
     wrapper = (function(nativeFunc) {
       return function(var_args) {
         return this.date[nativeFunc].apply(this.date, arguments);
       };
     })(functions[i]);
-    this.createNativeFunction('Date.prototype.' + functions[i], wrapper, false);
+    // Setters convert their arguments with ToNumber.
+    this.createNativeFunction('Date.prototype.' + functions[i], wrapper, false,
+        /^set/.test(functions[i]) ? 'number' : undefined);
   }
   functions = ['toLocaleDateString', 'toLocaleString', 'toLocaleTimeString'];
   for (var i = 0; i < functions.length; i++) {
@@ -1961,7 +2440,12 @@ Interpreter.prototype.initRegExp_ = function() {
           'Method RegExp.prototype.exec called on incompatible receiver' +
               this.toString());
     }
-    return this.regexp.test(str);
+    var perms = intrp.thread_.perms();
+    // Get lastIndex from wrapped regex, since this is settable.
+    this.regexp.lastIndex = this.get('lastIndex', perms);
+    var result = this.regexp.test(str);
+    this.set('lastIndex', this.regexp.lastIndex, perms);
+    return result;
   };
   this.createNativeFunction('RegExp.prototype.test', wrapper, false);
 
@@ -2034,6 +2518,35 @@ Interpreter.prototype.initError_ = function() {
   intrp.URI_ERROR = createErrorClass('URIError', 'URI_ERROR');
   intrp.PERM_ERROR = createErrorClass('PermissionError', 'PERM_ERROR');
 
+  new this.NativeFunction({
+    id: 'Error.captureStackTrace', length: 1,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      var obj = args[0];
+      var constructorOpt = args[1];
+      var perms = state.scope.perms;
+      if (!(obj instanceof intrp.Object)) {
+        throw new intrp.Error(perms, intrp.TYPE_ERROR,
+            'Error.captureStackTrace requires an object');
+      }
+      // Omit the frame for captureStackTrace itself, and (if supplied)
+      // all frames above and including the call to constructorOpt.
+      var callers = thread.callers(perms).slice(1);
+      if (constructorOpt instanceof intrp.Function) {
+        for (var i = 0; i < callers.length; i++) {
+          if (callers[i].func === constructorOpt) {
+            callers = callers.slice(i + 1);
+            break;
+          }
+        }
+      }
+      // Replace any existing .stack, since that is the point of calling.
+      obj.deleteProperty('stack', perms);
+      intrp.Error.prototype.makeStack.call(
+          /** @type {?} */ (obj), callers, perms);
+    }
+  });
+
   this.createNativeFunction('Error.prototype.toString',
                             this.Error.prototype.toString, false);
 };
@@ -2050,7 +2563,7 @@ Interpreter.prototype.initMath_ = function() {
       'trunc'];
   for (var i = 0; i < numFunctions.length; i++) {
     this.createNativeFunction('Math.' + numFunctions[i], Math[numFunctions[i]],
-                              false);
+                              false, 'number');
   }
 };
 
@@ -2234,10 +2747,7 @@ Interpreter.prototype.initThread_ = function() {
         throw new intrp.Error(perms, intrp.TYPE_ERROR, t + ' is not a Thread');
       }
       // TODO(cpcallen:perms): add security check here.
-      var id = t.thread.id;
-      if (intrp.threads_[id]) {
-        intrp.threads_[id].status = Interpreter.Thread.Status.ZOMBIE;
-      }
+      intrp.killThread(t.thread.id);
     }
   });
 
@@ -2301,37 +2811,316 @@ Interpreter.prototype.initThread_ = function() {
   };
 
   new this.NativeFunction({
-    id: 'Thread.prototype.getTimeLimit', length: 0,
+    id: 'Thread.prototype.getTimeLimit', length: 0,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: withChecks(function getTimeLimit(
+        intrp, thread, state, thisVal, args) {
+      return thisVal.thread.timeLimit;
+    })
+  });
+
+  // BUG(cpcallen): this only sets the time limit for future slices;
+  // until suspend is called the current Thread will run with its
+  // existing limit.
+  new this.NativeFunction({
+    id: 'Thread.prototype.setTimeLimit', length: 1,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: withChecks(function setTimeLimit(
+        intrp, thread, state, thisVal, args) {
+      var limit = args[0];
+      var perms = state.scope.perms;
+      var old = thisVal.thread.timeLimit || Number.MAX_VALUE;
+      if (typeof limit !== 'number' || Number.isNaN(limit)) {
+        throw new intrp.Error(perms, intrp.TYPE_ERROR,
+            'new limit must be a number (and not NaN)');
+      } else if (limit <= 0) {
+        throw new intrp.Error(perms, intrp.RANGE_ERROR,
+            'new limit must be > 0');
+      } else if (limit > old) {
+        throw new intrp.Error(perms, intrp.RANGE_ERROR,
+            'new limit must be <= previous limit');
+      }
+      thisVal.thread.timeLimit = limit;
+    })
+  });
+};
+
+/**
+ * Initialize the publish/subscribe API.  Publishing a value on a
+ * topic does not call subscribers directly; instead a new thread is
+ * created for each call, so that publishers and subscribers need not
+ * hold references to each other.
+ * @private
+ */
+Interpreter.prototype.initPubSub_ = function() {
+  new this.NativeFunction({
+    id: 'CC.subscribe', length: 2,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      var topic = args[0];
+      var func = args[1];
+      var perms = state.scope.perms;
+      if (typeof topic !== 'string') {
+        throw new intrp.Error(perms, intrp.TYPE_ERROR,
+            'topic must be a string');
+      } else if (!(func instanceof intrp.Function)) {
+        throw new intrp.Error(perms, intrp.TYPE_ERROR,
+            func + ' is not a function');
+      }
+      var subscribers = intrp.subscriptions_.get(topic);
+      if (!subscribers) {
+        subscribers = new Map;
+        intrp.subscriptions_.set(topic, subscribers);
+      }
+      // func will be called with the perms it was subscribed with.
+      subscribers.set(func, perms);
+    }
+  });
+
+  new this.NativeFunction({
+    id: 'CC.unsubscribe', length: 2,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      var topic = args[0];
+      var func = args[1];
+      var subscribers = intrp.subscriptions_.get(String(topic));
+      if (!subscribers || !subscribers.delete(func)) {
+        return false;
+      }
+      if (!subscribers.size) {
+        intrp.subscriptions_.delete(String(topic));
+      }
+      return true;
+    }
+  });
+
+  new this.NativeFunction({
+    id: 'CC.publish', length: 2,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      var topic = args[0];
+      var value = args[1];
+      var perms = state.scope.perms;
+      if (typeof topic !== 'string') {
+        throw new intrp.Error(perms, intrp.TYPE_ERROR,
+            'topic must be a string');
+      }
+      var subscribers = intrp.subscriptions_.get(topic);
+      if (!subscribers) {
+        return 0;
+      }
+      subscribers.forEach(function(owner, func) {
+        intrp.createThreadForFuncCall(owner, func, undefined, [value, topic],
+            undefined, thread.timeLimit);
+      });
+      return subscribers.size;
+    }
+  });
+};
+
+/**
+ * Initialize the property change observation API.
+ * @private
+ */
+Interpreter.prototype.initObserve_ = function() {
+  new this.NativeFunction({
+    id: 'CC.observe', length: 2,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      var obj = args[0];
+      var func = args[1];
+      var perms = state.scope.perms;
+      if (!(obj instanceof intrp.Object)) {
+        throw new intrp.Error(perms, intrp.TYPE_ERROR,
+            obj + ' is not an object');
+      } else if (!(func instanceof intrp.Function)) {
+        throw new intrp.Error(perms, intrp.TYPE_ERROR,
+            func + ' is not a function');
+      }
+      var observers = intrp.observers_.get(obj);
+      if (!observers) {
+        observers = new Map;
+        intrp.observers_.set(obj, observers);
+      }
+      // func will be called with the perms it was registered with.
+      observers.set(func, perms);
+    }
+  });
+
+  new this.NativeFunction({
+    id: 'CC.unobserve', length: 2,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      var obj = args[0];
+      var func = args[1];
+      var observers =
+          obj instanceof intrp.Object ? intrp.observers_.get(obj) : undefined;
+      if (!observers || !observers.delete(func)) {
+        return false;
+      }
+      if (!observers.size) {
+        intrp.observers_.delete(obj);
+      }
+      return true;
+    }
+  });
+};
+
+/**
+ * Initialize the value encoding API.
+ * @private
+ */
+Interpreter.prototype.initEncoding_ = function() {
+  new this.NativeFunction({
+    id: 'CC.encode', length: 1,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      return intrp.encodeValue(args[0], state.scope.perms);
+    }
+  });
+
+  new this.NativeFunction({
+    id: 'CC.decode', length: 1,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      return intrp.decodeValue(String(args[0]), state.scope.perms);
+    }
+  });
+};
+
+/**
+ * Initialize the maintenance mode, slow task log and blocked thread API.
+ * @private
+ */
+Interpreter.prototype.initMaintenance_ = function() {
+  new this.NativeFunction({
+    id: 'CC.setMaintenance', length: 1,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      var message = args[0];
+      if (message === undefined || message === null) {
+        intrp.setMaintenance(null);
+      } else {
+        intrp.setMaintenance(String(message));
+      }
+    }
+  });
+
+  new this.NativeFunction({
+    id: 'CC.getMaintenance', length: 0,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      return intrp.maintenanceMessage;
+    }
+  });
+
+  new this.NativeFunction({
+    id: 'CC.slowTasks', length: 0,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      return intrp.nativeToPseudo(intrp.slowTasks_, state.scope.perms);
+    }
+  });
+
+  new this.NativeFunction({
+    id: 'CC.blockedThreads', length: 0,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      var perms = state.scope.perms;
+      var now = intrp.now();
+      var blocked = [];
+      for (var i = 0; i < intrp.threads_.length; i++) {
+        var t = intrp.threads_[i];
+        if (!t || t.status !== Interpreter.Thread.Status.BLOCKED) continue;
+        var info = intrp.nativeToPseudo({
+          thread: t.id,
+          waiting: now - t.blockedSince,
+          stack: intrp.formatCallers(t.callers(perms), perms),
+        }, perms);
+        info.defineProperty('on', Descriptor.wec.withValue(t.blockedOn), perms);
+        blocked.push(info);
+      }
+      blocked.sort(function(a, b) {
+        return b.get('waiting', perms) - a.get('waiting', perms);
+      });
+      return intrp.createArrayFromList(blocked, perms);
+    }
+  });
+};
+
+/**
+ * Initialize the code checking, testing and editing (linter,
+ * formatter, deep equality and function patching) API.
+ * @private
+ */
+Interpreter.prototype.initCodeTools_ = function() {
+  new this.NativeFunction({
+    id: 'CC.lint', length: 1,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      var perms = state.scope.perms;
+      var src = String(args[0]);
+      try {
+        var warnings = lint(src, function(name) {
+          return intrp.global.hasBinding(name);
+        });
+      } catch (e) {  // Acorn threw a SyntaxError.
+        throw intrp.errorNativeToPseudo(e, perms);
+      }
+      return intrp.nativeToPseudo(warnings, perms);
+    }
+  });
+
+  new this.NativeFunction({
+    id: 'CC.format', length: 2,
+    /** @type {!Interpreter.NativeCallImpl} */
+    call: function(intrp, thread, state, thisVal, args) {
+      var src = String(args[0]);
+      var indent = args[1];
+      if (typeof indent !== 'number' && typeof indent !== 'string') {
+        indent = undefined;
+      }
+      try {
+        return format(src, indent);
+      } catch (e) {  // Acorn threw a SyntaxError.
+        throw intrp.errorNativeToPseudo(e, state.scope.perms);
+      }
+    }
+  });
+
+  new this.NativeFunction({
+    id: 'CC.deepEqual', length: 2,
     /** @type {!Interpreter.NativeCallImpl} */
-    call: withChecks(function getTimeLimit(
-        intrp, thread, state, thisVal, args) {
-      return thisVal.thread.timeLimit;
-    })
+    call: function(intrp, thread, state, thisVal, args) {
+      return intrp.deepEqual(args[0], args[1], state.scope.perms);
+    }
   });
 
-  // BUG(cpcallen): this only sets the time limit for future slices;
-  // until suspend is called the current Thread will run with its
-  // existing limit.
   new this.NativeFunction({
-    id: 'Thread.prototype.setTimeLimit', length: 1,
+    id: 'CC.patchFunction', length: 2,
     /** @type {!Interpreter.NativeCallImpl} */
-    call: withChecks(function setTimeLimit(
-        intrp, thread, state, thisVal, args) {
-      var limit = args[0];
+    call: function(intrp, thread, state, thisVal, args) {
+      var func = args[0];
+      var replacement = args[1];
       var perms = state.scope.perms;
-      var old = thisVal.thread.timeLimit || Number.MAX_VALUE;
-      if (typeof limit !== 'number' || Number.isNaN(limit)) {
-        throw new intrp.Error(perms, intrp.TYPE_ERROR,
-            'new limit must be a number (and not NaN)');
-      } else if (limit <= 0) {
-        throw new intrp.Error(perms, intrp.RANGE_ERROR,
-            'new limit must be > 0');
-      } else if (limit > old) {
-        throw new intrp.Error(perms, intrp.RANGE_ERROR,
-            'new limit must be <= previous limit');
+      if (!(func instanceof intrp.UserFunction) ||
+          !(replacement instanceof intrp.UserFunction) ||
+          func === replacement) {
+        return undefined;
       }
-      thisVal.thread.timeLimit = limit;
-    })
+      // TODO(cpcallen:perms): throw if current perms does not
+      // control func.
+      intrp.checkNotFrozen_(func, perms);
+      intrp.checkNotReadOnly_(func, perms);
+      // Make a new function, closing over the same scope, which
+      // retains the old body so that the edit can be undone.
+      var previous = new intrp.UserFunction(func.node, func.scope,
+          new Interpreter.Source(func.toString()), func.owner);
+      // Activations already in progress have the old body node on
+      // their stateStack_ and so will run to completion unaffected.
+      func.node = replacement.node;
+      return previous;
+    }
   });
 };
 
@@ -2422,6 +3211,7 @@ Interpreter.prototype.initNetwork_ = function() {
       var proto = args[1];
       var timeLimit = Number(args[2]) || thread.timeLimit;
       var perms = state.scope.perms;
+      intrp.checkNotReadOnlyMode_(perms, 'connectionListen');
       if (port !== (port >>> 0) || port > 0xffff) {
         throw new intrp.Error(perms, intrp.RANGE_ERROR, 'invalid port');
       } else if (port in intrp.listeners_) {
@@ -2512,6 +3302,7 @@ Interpreter.prototype.initNetwork_ = function() {
     call: function(intrp, thread, state, thisVal, args) {
       var url = String(args[0]);
       var perms = state.scope.perms;
+      intrp.checkNotReadOnlyMode_(perms, 'xhr');
       if (url.match(/^http:\/\//)) {
         var req = http.get(url);
       } else if (url.match(/^https:\/\//)) {
@@ -2599,10 +3390,13 @@ Interpreter.toLength = function toLength(value) {
  * @param {!Function} nativeFunc JavaScript function.
  * @param {boolean} legalConstructor True if the function can be used as a
  *     constructor (e.g. Array), false if not (e.g. escape).
+ * @param {!Interpreter.ToPrimitiveHint=} argHints If supplied, object
+ *     arguments will be converted to primitives with the given hint(s)
+ *     before being passed to nativeFunc.
  * @return {!Interpreter.prototype.Function} New function.
 */
 Interpreter.prototype.createNativeFunction = function(
-    name, nativeFunc, legalConstructor) {
+    name, nativeFunc, legalConstructor, argHints) {
   if (nativeFunc instanceof this.Object) {
     throw new TypeError('createNativeFunction passed non-native function??');
   }
@@ -2610,6 +3404,9 @@ Interpreter.prototype.createNativeFunction = function(
   if (!nativeFunc.id) {
     nativeFunc.id = name;
   }
+  if (argHints) {
+    nativeFunc.argHints = argHints;
+  }
   var func = new this.OldNativeFunction(nativeFunc, legalConstructor);
   func.setName(name.replace(/^.*\./, ''));
   this.builtins.set(name, func);
@@ -2755,6 +3552,277 @@ Interpreter.prototype.pseudoToNative = function(pseudoObj, cycles) {
   return nativeObj;
 };
 
+/**
+ * Compare two JS interpreter values for structural equality.
+ * Primitives are compared using SameValueZero.  Objects are equal if
+ * they are identical, or if they have the same [[Class]] and
+ * prototype, the same own property keys (in any order) and
+ * structurally equal property values; Dates and RegExps must also
+ * have the same time value or pattern and flags.  Functions are
+ * equal only if identical.  Cycles are handled by treating any pair
+ * of objects already being compared as equal.
+ * @param {?Interpreter.Value} a The first value.
+ * @param {?Interpreter.Value} b The second value.
+ * @param {!Interpreter.Owner} perms Who is doing the comparison?
+ * @param {!Map<!Interpreter.prototype.Object,
+ *     !Set<!Interpreter.prototype.Object>>=} visiting Cycle detection
+ *     (used only in recursive calls).
+ * @return {boolean} True iff a and b are structurally equal.
+ */
+Interpreter.prototype.deepEqual = function(a, b, perms, visiting) {
+  if (a === b || (a !== a && b !== b)) {  // N.B.: NaN !== NaN.
+    return true;
+  } else if (!(a instanceof this.Object) || !(b instanceof this.Object) ||
+      a instanceof this.Function || b instanceof this.Function ||
+      a.class !== b.class || a.proto !== b.proto) {
+    return false;
+  } else if (a instanceof this.Date &&
+      a.date.getTime() !== /** @type {?} */(b).date.getTime()) {
+    return false;
+  } else if (a instanceof this.RegExp &&
+      String(a.regexp) !== String(/** @type {?} */(b).regexp)) {
+    return false;
+  }
+
+  if (!visiting) visiting = new Map();
+  var pairs = visiting.get(a);
+  if (pairs && pairs.has(b)) return true;  // Already being compared.
+  if (!pairs) visiting.set(a, pairs = new Set());
+  pairs.add(b);
+
+  var keys = a.ownKeys(perms);
+  if (keys.length !== b.ownKeys(perms).length) return false;
+  for (var i = 0; i < keys.length; i++) {
+    var key = keys[i];
+    if (!b.getOwnPropertyDescriptor(key, perms) ||
+        !this.deepEqual(a.get(key, perms), b.get(key, perms), perms,
+            visiting)) {
+      return false;
+    }
+  }
+  return true;
+};
+
+/**
+ * Encode a JS interpreter value, and all the objects reachable from
+ * it via their properties, as a string.  The result can later be
+ * passed to .decodeValue to reconstruct a copy of the value, in this
+ * or another interpreter.
+ *
+ * The encoding is JSON, with the structure
+ * {"value": VALUE, "objects": [RECORD, ...]}, where each RECORD
+ * describes one object: its "type" ([[Class]]), prototype (if it is
+ * not the default for its type), property values and attributes,
+ * extensibility, and the time value of Dates or pattern and flags of
+ * RegExps.  VALUEs are encoded as for the serializer: primitives as
+ * themselves (with special encodings for undefined, NaN, etc.),
+ * objects as {"#": INDEX} references into the objects list, and
+ * builtins (e.g. Math.max or Object.prototype) by name as
+ * {"Builtin": NAME}, so they are shared rather than copied.  Cycles
+ * are preserved.
+ *
+ * Only plain objects, arrays, Dates, RegExps and Errors can be
+ * encoded.  Prototypes other than builtins are not preserved (the
+ * default prototype for the object's type is used instead).
+ * @param {?Interpreter.Value} value The value to encode.
+ * @param {!Interpreter.Owner} perms Who is doing the encoding?
+ * @return {string} The encoded value.
+ * @throws {!Interpreter.prototype.Error} TypeError if value or any
+ *     object reachable from it cannot be encoded.
+ */
+Interpreter.prototype.encodeValue = function(value, perms) {
+  var intrp = this;
+  var builtinNames = new Map;
+  var entries = this.builtins.entries();
+  for (var i = 0; i < entries.length; i++) {
+    if (entries[i][1] instanceof this.Object) {
+      builtinNames.set(entries[i][1], entries[i][0]);
+    }
+  }
+  var records = [];
+  var refs = new Map;
+
+  function encode(value) {
+    if (value instanceof intrp.Object) {
+      var name = builtinNames.get(value);
+      if (name !== undefined) return {'Builtin': name};
+      var ref = refs.get(value);
+      if (ref === undefined) ref = encodeObject(value);
+      return {'#': ref};
+    } else if (value === undefined) {
+      return {'Value': 'undefined'};
+    } else if (typeof value === 'number' &&
+        (!isFinite(value) || Object.is(value, -0))) {
+      return {'Number': Object.is(value, -0) ? '-0' : String(value)};
+    }
+    return value;
+  }
+
+  function encodeObject(obj) {
+    var record = {'type': obj.class};
+    var ref = records.length;
+    records.push(record);
+    refs.set(obj, ref);
+    var skip = [];
+    var defaultProto;
+    if (obj instanceof intrp.Function || obj instanceof intrp.Arguments ||
+        obj instanceof intrp.WeakMap || obj instanceof intrp.Thread) {
+      throw new intrp.Error(perms, intrp.TYPE_ERROR,
+          obj.class + ' objects cannot be encoded');
+    } else if (obj instanceof intrp.Array) {
+      defaultProto = intrp.ARRAY;
+      skip = ['length'];
+      record['length'] = obj.get('length', perms);
+    } else if (obj instanceof intrp.Date) {
+      defaultProto = intrp.DATE;
+      record['data'] = encode(obj.date.getTime());
+    } else if (obj instanceof intrp.RegExp) {
+      defaultProto = intrp.REGEXP;
+      skip = ['source', 'global', 'ignoreCase', 'multiline'];
+      record['source'] = obj.regexp.source;
+      record['flags'] = obj.regexp.flags;
+    } else if (obj instanceof intrp.Error) {
+      defaultProto = intrp.ERROR;
+    } else {
+      defaultProto = intrp.OBJECT;
+    }
+    if (obj.proto !== defaultProto &&
+        (obj.proto === null || builtinNames.has(obj.proto))) {
+      record['proto'] = encode(obj.proto);
+    }
+    var props = {};
+    var nonWritable = [];
+    var nonEnumerable = [];
+    var nonConfigurable = [];
+    var keys = obj.ownKeys(perms);
+    for (var i = 0; i < keys.length; i++) {
+      var key = keys[i];
+      if (skip.includes(key)) continue;
+      var pd = obj.getOwnPropertyDescriptor(key, perms);
+      props[key] = encode(pd.value);
+      if (!pd.writable) nonWritable.push(key);
+      if (!pd.enumerable) nonEnumerable.push(key);
+      if (!pd.configurable) nonConfigurable.push(key);
+    }
+    record['props'] = props;
+    if (nonWritable.length) record['nonWritable'] = nonWritable;
+    if (nonEnumerable.length) record['nonEnumerable'] = nonEnumerable;
+    if (nonConfigurable.length) record['nonConfigurable'] = nonConfigurable;
+    if (!obj.isExtensible(perms)) record['nonExtensible'] = true;
+    return ref;
+  }
+
+  var encoded = encode(value);
+  return JSON.stringify({'value': encoded, 'objects': records});
+};
+
+/**
+ * Decode a string produced by .encodeValue, creating new objects
+ * (owned by owner) as required.
+ * @param {string} text The encoded value.
+ * @param {!Interpreter.Owner} owner Owner for new objects.
+ * @return {?Interpreter.Value} The decoded value.
+ * @throws {!Interpreter.prototype.Error} SyntaxError if text is not
+ *     a valid encoding.
+ */
+Interpreter.prototype.decodeValue = function(text, owner) {
+  var intrp = this;
+  var invalid = function(message) {
+    return new intrp.Error(owner, intrp.SYNTAX_ERROR,
+        'Invalid encoded value: ' + message);
+  };
+  try {
+    var json = JSON.parse(text);
+  } catch (e) {
+    throw invalid(e.message);
+  }
+  if (!json || typeof json !== 'object' || !Array.isArray(json['objects'])) {
+    throw invalid('missing objects list');
+  }
+  var records = json['objects'];
+  var objects = [];
+
+  function decode(value) {
+    if (!value || typeof value !== 'object') {
+      return value;
+    } else if ('#' in value) {
+      var obj = objects[value['#']];
+      if (!obj) throw invalid('bad reference ' + value['#']);
+      return obj;
+    } else if ('Builtin' in value) {
+      if (!intrp.builtins.has(value['Builtin'])) {
+        throw invalid('unknown builtin ' + value['Builtin']);
+      }
+      return intrp.builtins.get(value['Builtin']);
+    } else if (value['Value'] === 'undefined') {
+      return undefined;
+    } else if ('Number' in value) {
+      return Number(value['Number']);
+    }
+    throw invalid('unrecognised value ' + JSON.stringify(value));
+  }
+
+  // First pass: create an object for each record, so that references
+  // (including cyclic ones) can be resolved in the second pass.
+  for (var i = 0; i < records.length; i++) {
+    var record = records[i];
+    var obj;
+    switch (record && record['type']) {
+      case 'Object':
+        obj = new this.Object(owner);
+        break;
+      case 'Array':
+        obj = new this.Array(owner);
+        break;
+      case 'Date':
+        obj = new this.Date(new Date(decode(record['data'])), owner);
+        break;
+      case 'RegExp':
+        try {
+          var re = new RegExp(record['source'], record['flags']);
+        } catch (e) {
+          throw invalid(e.message);
+        }
+        obj = new this.RegExp(re, owner);
+        break;
+      case 'Error':
+        obj = new this.Error(owner);
+        break;
+      default:
+        throw invalid('unknown type ' + (record && record['type']));
+    }
+    objects.push(obj);
+  }
+  // Second pass: set prototypes and properties.
+  for (var i = 0; i < records.length; i++) {
+    var record = records[i];
+    var obj = objects[i];
+    if ('proto' in record) {
+      var proto = decode(record['proto']);
+      if (proto !== null && !(proto instanceof this.Object)) {
+        throw invalid('bad prototype');
+      }
+      obj.setPrototypeOf(proto, owner);
+    }
+    var props = record['props'] || {};
+    var nonWritable = record['nonWritable'] || [];
+    var nonEnumerable = record['nonEnumerable'] || [];
+    var nonConfigurable = record['nonConfigurable'] || [];
+    for (var key in props) {
+      var desc = new Descriptor(!nonWritable.includes(key),
+          !nonEnumerable.includes(key), !nonConfigurable.includes(key));
+      obj.defineProperty(key, desc.withValue(decode(props[key])), owner);
+    }
+    if (record['type'] === 'Array') {
+      obj.set('length', record['length'], owner);
+    }
+    if (record['nonExtensible']) {
+      obj.preventExtensions(owner);
+    }
+  }
+  return decode(json['value']);
+};
+
 /**
  * CreateArrayFromList from ES6 §7.3.16
  *
@@ -2980,6 +4048,233 @@ Interpreter.prototype.setValue = function(ref, value, perms) {
   }
 };
 
+/**
+ * Extra info used while converting object values to primitives (by
+ * the BinaryExpression, AssignmentExpression, UnaryExpression,
+ * UpdateExpression and MemberExpression step functions, and by the
+ * Call step function for native functions with argument hints):
+ * - operands: the (partially converted) operands.
+ * - n: index of the operand currently being converted.
+ * - tried: number of conversion methods already tried on that operand.
+ * @typedef {{operands: !Array<?Interpreter.Value>,
+ *            n: number,
+ *            tried: number}}
+ */
+Interpreter.ToPrimitiveInfo;
+
+/**
+ * Hint(s) for ToPrimitive: 'default', 'number' or 'string' (as for
+ * the PreferredType argument of ES6 §7.1.1), applying to every
+ * operand, or an array giving the hint for each operand in turn;
+ * operands with an undefined hint are not converted.
+ * @typedef {string|!Array<(string|undefined)>}
+ */
+Interpreter.ToPrimitiveHint;
+
+/**
+ * Will applying the given binary operator to left and right require
+ * either of them to be converted to a primitive by ToPrimitive?
+ * @private
+ * @param {string} op The binary operator (e.g., '+', '==').
+ * @param {?Interpreter.Value} left The left operand.
+ * @param {?Interpreter.Value} right The right operand.
+ * @return {boolean} True iff an object operand needs converting.
+ */
+Interpreter.prototype.needsToPrimitive_ = function(op, left, right) {
+  var leftIsObject = left instanceof this.Object;
+  var rightIsObject = right instanceof this.Object;
+  if (!leftIsObject && !rightIsObject) return false;
+  switch (op) {
+    case '===':
+    case '!==':
+    case 'instanceof':
+      return false;
+    case 'in':
+      // Only the key is converted (by ToPropertyKey), and only once
+      // it is known that the right operand is an object.
+      return leftIsObject && rightIsObject;
+    case '==':
+    case '!=':
+      // Objects are only ever loosely equal to themselves, and never
+      // to null or undefined.  ES5.1 §11.9.3.
+      return leftIsObject !== rightIsObject && left != null && right != null;
+    default:
+      return true;
+  }
+};
+
+/**
+ * The ToPrimitive specification method from ES6 §7.1.1, applied to
+ * each of the operands recorded in info.  Object operands are
+ * converted by calling their userland .valueOf and .toString methods
+ * (in the order given by the hint, or by [[DefaultValue]], ES5.1
+ * §8.12.8, if the hint is 'default'), so this must be called
+ * repeatedly from the step function: it returns a State to call the
+ * next conversion method, which the step function should return, or
+ * (once every operand has been converted, in place) null.  The
+ * completion value of each conversion method call is expected to be
+ * found in state.value on the subsequent call.
+ * @private
+ * @param {!Interpreter.State} state The state of the step function.
+ * @param {!Interpreter.ToPrimitiveInfo} info Conversion progress.
+ * @param {!Interpreter.ToPrimitiveHint} hint The hint(s) to use.
+ * @return {?Interpreter.State}
+ */
+Interpreter.prototype.toPrimitive_ = function(state, info, hint) {
+  var perms = state.scope.perms;
+  if (info.tried > 0 && !(state.value instanceof this.Object)) {
+    // Call of .valueOf or .toString returned a primitive.
+    info.operands[info.n++] = state.value;
+    info.tried = 0;
+  }
+  while (info.n < info.operands.length) {
+    var value = info.operands[info.n];
+    var h = Array.isArray(hint) ? hint[info.n] : hint;
+    if (!(value instanceof this.Object) || h === undefined) {
+      info.n++;
+      continue;
+    }
+    // With no hint, Dates default to hint String; everything else
+    // to hint Number.
+    var hintString =
+        h === 'string' || (h === 'default' && value instanceof this.Date);
+    var methods =
+        hintString ? ['toString', 'valueOf'] : ['valueOf', 'toString'];
+    while (info.tried < methods.length) {
+      var func = value.get(methods[info.tried++], perms);
+      if (func instanceof this.Function) {
+        return Interpreter.State.newForCall(func, value, [], perms);
+      }
+    }
+    throw new this.Error(perms, this.TYPE_ERROR,
+        'Cannot convert object to primitive value');
+  }
+  return null;
+};
+
+/**
+ * Get the hint(s) with which the arguments of a call to func should
+ * be converted to primitives before the call takes place, as
+ * declared when the native function was created.
+ * @private
+ * @param {!Interpreter.prototype.Function} func The function to be called.
+ * @param {boolean} construct Is this a [[Construct]] call?
+ * @return {!Interpreter.ToPrimitiveHint|undefined} The hint(s), or
+ *     undefined if the arguments should be passed unconverted.
+ */
+Interpreter.prototype.argHints_ = function(func, construct) {
+  if (func instanceof this.OldNativeFunction) {
+    return func.impl.argHints;
+  } else if (func instanceof this.NativeFunction) {
+    var impl = construct ? func.construct : func.call;
+    return impl.argHints;
+  }
+  return undefined;
+};
+
+/**
+ * Convert the operands of an operator (or similar) to primitives
+ * using .toPrimitive_, recording progress in state.info_ (which must
+ * be null on the first call).  Returns a State to call the next
+ * conversion method, which the step function should return, or (once
+ * done) the converted operands.
+ * @private
+ * @param {!Interpreter.State} state The state of the step function.
+ * @param {!Array<?Interpreter.Value>} operands The operands.  Ignored
+ *     on subsequent calls.
+ * @param {!Interpreter.ToPrimitiveHint} hint The hint(s) to use.
+ * @return {!Interpreter.State|!Array<?Interpreter.Value>}
+ */
+Interpreter.prototype.toPrimitiveOperands_ = function(state, operands, hint) {
+  var info = /** @type {?Interpreter.ToPrimitiveInfo} */(state.info_);
+  if (!info) {
+    info = {operands: operands, n: 0, tried: 0};
+    state.info_ = info;
+  }
+  var next = this.toPrimitive_(state, info, hint);
+  if (next) return next;
+  state.info_ = null;
+  return info.operands;
+};
+
+/**
+ * Format call stack information (as returned by
+ * Thread.prototype.callers) as a stack trace, one frame per line.
+ * @param {!Array<!FrameInfo>} callers List of call stack frames.
+ * @param {!Interpreter.Owner} perms Whose perms should be used to
+ *     obtain (e.g.) function names, etc.?
+ * @return {string} The formatted stack trace.
+ */
+Interpreter.prototype.formatCallers = function(callers, perms) {
+  var stack = [];
+  for (var i = 0; i < callers.length; i++) {
+    var /** string */ line = '    ';
+    var frame = callers[i];
+    if ('func' in frame) {
+      var /** !Interpreter.prototype.Function */ func = frame.func;
+      var /** string */ name;
+      try {
+        var pd = func.getOwnPropertyDescriptor('name', perms);
+        if (pd) {
+          name = String(pd.value);
+        } else {
+          name = 'anonymous function';
+        }
+      } catch (e) {
+        name = 'unreadable function';
+      }
+    } else if ('eval' in frame) {
+      name = '"' + frame.eval + '"';
+    } else if ('file' in frame) {
+      name = frame.file;
+    } else if ('program' in frame) {
+      name = '"' + frame.program + '"';
+    }
+    if ('line' in frame) {
+      line += 'at ' + name + ' ' + frame.line + ':' + frame.col;
+    } else {
+      line += 'in ' + name;
+    }
+    stack.push(line);
+  }
+  return stack.join('\n');
+};
+
+/**
+ * Check to see if the given thread, which has been running without
+ * interruption since start, has exceeded the .slowTaskMs or
+ * .slowTaskSteps thresholds.  If so, log it along with its current
+ * stack and record it in .slowTasks_ (which retains only the most
+ * recent Interpreter.SLOW_TASK_LOG_SIZE entries).
+ * @private
+ * @param {!Interpreter.Thread} thread The running thread.
+ * @param {number} start Time (as returned by .now()) thread started running.
+ * @param {number} steps Number of steps executed since start.
+ * @return {boolean} True iff the thread was reported as slow.
+ */
+Interpreter.prototype.checkSlowTask_ = function(thread, start, steps) {
+  var elapsed = this.now() - start;
+  if (!(this.options.slowTaskMs && elapsed >= this.options.slowTaskMs) &&
+      !(this.options.slowTaskSteps && steps >= this.options.slowTaskSteps)) {
+    return false;
+  }
+  var task = {
+    time: Date.now(),
+    thread: thread.id,
+    elapsed: elapsed,
+    steps: steps,
+    stack: this.formatCallers(thread.callers(this.ROOT), this.ROOT),
+  };
+  this.slowTasks_.push(task);
+  if (this.slowTasks_.length > Interpreter.SLOW_TASK_LOG_SIZE) {
+    this.slowTasks_.shift();
+  }
+  this.log('slow', 'Slow task: thread %d has run for %d ms (%d steps)',
+      task.thread, task.elapsed, task.steps);
+  this.log('slow', task.stack);
+  return true;
+};
+
 /**
  * Check to see if the current thread has run too long.  Called at the
  * top of loops and before making function calls.
@@ -3137,11 +4432,13 @@ Interpreter.prototype.getResolveReject = function(thread, state) {
     resolve: function resolve(value) {
       check();
       state.value = value;
+      thread.unblock();
       thread.status = Interpreter.Thread.Status.READY;
       intrp.go_();
     },
     reject: function reject(value, perms) {
       check();
+      thread.unblock();
       thread.status = Interpreter.Thread.Status.READY;
       intrp.throw_(thread, value, perms);
       intrp.go_();
@@ -3217,10 +4514,42 @@ Interpreter.FunctionResult.CallAgain = new Interpreter.FunctionResult;
  */
 Interpreter.FunctionResult.Sleep = new Interpreter.FunctionResult;
 
+/**
+ * Information about a slow task: a thread which ran for longer than
+ * the .slowTaskMs or .slowTaskSteps option without suspending.
+ * @typedef {{time: number, thread: number, elapsed: number, steps: number,
+ *            stack: string}}
+ */
+Interpreter.SlowTask;
+
+/**
+ * Callback for property change observers registered with
+ * Interpreter.prototype.observe.  Called with the observed object,
+ * the key of the changed property and the type of change ('set' or
+ * 'delete').
+ * @typedef {function(!Interpreter.prototype.Object, string, string)}
+ */
+Interpreter.ObserverCallback;
+
+/**
+ * Number of steps between checks for slow tasks.
+ * @const {number}
+ */
+Interpreter.SLOW_TASK_CHECK_STEPS = 1000;
+
+/**
+ * Maximum number of slow tasks retained in .slowTasks_.
+ * @const {number}
+ */
+Interpreter.SLOW_TASK_LOG_SIZE = 100;
+
 /**
  * Options object for Interpreter constructor.
  * @typedef {{
+ *     blockedThreadMs: (number|undefined),
  *     noLog: (!Array<string>|undefined),
+ *     slowTaskMs: (number|undefined),
+ *     slowTaskSteps: (number|undefined),
  *     trimEval: (boolean|undefined),
  *     trimProgram: (boolean|undefined),
  *     stackLimit: (number|undefined),
@@ -3598,6 +4927,7 @@ Interpreter.State = function(node, scope, wantRef) {
   /** @private @type {?Interpreter.CallInfo|
    *                  ?Interpreter.ForInInfo|
    *                  ?Interpreter.SwitchInfo|
+   *                  ?Interpreter.ToPrimitiveInfo|
    *                  ?Interpreter.Completion}
    */
   this.info_ = null;
@@ -3625,7 +4955,8 @@ Interpreter.State.newForCall = function(func, thisVal, args, perms) {
                  arguments: args,
                  directEval: false,
                  construct: false,
-                 funcState: undefined};
+                 funcState: undefined,
+                 toPrimitive: null};
   return state;
 };
 
@@ -3639,8 +4970,10 @@ Interpreter.State.newForCall = function(func, thisVal, args, perms) {
  *             callerPerms: !Interpreter.Owner,
  *             line: number,
  *             col: number}|
- *           !{program: string}|
  *           !{program: string,
+ *             file: (string|undefined)}|
+ *           !{program: string,
+ *             file: (string|undefined),
  *             line: number,
  *             col: number}|
  *           !{eval: string}|
@@ -3670,6 +5003,8 @@ Interpreter.State.prototype.frame = function() {
     case 'Program':
       var source = this.node['source'];
       if (!source) throw new Error('No source for Program??');
+      var filename = this.node['filename'];
+      if (filename) return {program: String(source), file: filename};
       return {program: String(source)};
     case 'EvalProgram_':
       source = this.node['source'];
@@ -3710,6 +5045,36 @@ Interpreter.Thread = function(id, state, runAt, timeLimit) {
   this.wrapper = null;
   /** @type {?Interpreter.Value} */
   this.value = undefined;
+  /**
+   * If thread is BLOCKED, the time (as returned by Interpreter
+   * .now()) at which it became blocked, and the function whose call
+   * it is waiting on.
+   * @type {number}
+   */
+  this.blockedSince = 0;
+  /** @type {?Interpreter.prototype.Function} */
+  this.blockedOn = null;
+  /**
+   * Has thread been reported as blocked for too long?  See
+   * Interpreter.prototype.reportBlockedThread_.
+   * @type {boolean}
+   */
+  this.blockedReported = false;
+  /**
+   * Was thread created to handle a network event?  See
+   * Interpreter.prototype.createThreadForNetwork_.
+   * @type {boolean}
+   */
+  this.network = false;
+};
+
+/**
+ * Clear the record of what a (formerly BLOCKED) thread was waiting on.
+ */
+Interpreter.Thread.prototype.unblock = function() {
+  this.blockedSince = 0;
+  this.blockedOn = null;
+  this.blockedReported = false;
 };
 
 /**
@@ -3761,6 +5126,160 @@ Interpreter.Thread.prototype.callers = function(perms) {
   return frames;
 };
 
+/**
+ * Return a description of the thread's state stack, the scopes
+ * referred to by those states and the objects referenced from those
+ * states and scopes, suitable for converting to JSON or (via
+ * Interpreter.Thread.graphToDot) to DOT for visualisation.  Intended
+ * to help when debugging the interpreter itself.
+ *
+ * Objects referenced directly from a state or scope have their
+ * own properties included; objects referenced only from other
+ * objects' properties are listed (so they can be drawn) but not
+ * expanded.  Primitive values are described by a string.
+ * @param {!Interpreter.Owner} perms Who wants the graph?
+ * @return {{states: !Array<!Object>, scopes: !Array<!Object>,
+ *     objects: !Array<!Object>}} The graph.
+ */
+Interpreter.Thread.prototype.graph = function(perms) {
+  var graph = {states: [], scopes: [], objects: []};
+  var ids = new Map();
+
+  /**
+   * @param {?Interpreter.Value} value Value to describe.
+   * @param {boolean} expand Include properties of object values?
+   * @return {string|{ref: string}} Description of value.
+   */
+  function describe(value, expand) {
+    if (value !== null && typeof value === 'object') {
+      return {ref: objectId(/** @type {!Interpreter.ObjectLike} */(value),
+          expand)};
+    } else if (typeof value === 'string') {
+      return JSON.stringify(value);
+    }
+    return String(value);
+  }
+
+  /**
+   * @param {!Interpreter.ObjectLike} obj Object to add to graph.
+   * @param {boolean} expand Include properties?
+   * @return {string} Object's ID in graph.
+   */
+  function objectId(obj, expand) {
+    var id = ids.get(obj);
+    if (id === undefined) {
+      id = 'object' + graph.objects.length;
+      ids.set(obj, id);
+      graph.objects.push({id: id, class: obj.class, properties: null});
+    }
+    var entry = graph.objects[Number(id.slice('object'.length))];
+    if (expand && !entry.properties) {
+      entry.properties = {};
+      var keys = obj.ownKeys(perms);
+      for (var i = 0; i < keys.length; i++) {
+        entry.properties[keys[i]] = describe(obj.get(keys[i], perms), false);
+      }
+    }
+    return id;
+  }
+
+  /**
+   * @param {?Interpreter.Scope} scope Scope to add to graph.
+   * @return {?string} Scope's ID in graph, or null if scope is null.
+   */
+  function scopeId(scope) {
+    if (!scope) return null;
+    var id = ids.get(scope);
+    if (id !== undefined) return id;
+    id = 'scope' + graph.scopes.length;
+    ids.set(scope, id);
+    var entry = {id: id, type: scope.type, outer: null, vars: {}};
+    graph.scopes.push(entry);
+    for (var name in scope.vars) {
+      entry.vars[name] = describe(scope.vars[name], true);
+    }
+    entry.outer = scopeId(scope.outerScope);
+    return id;
+  }
+
+  for (var i = 0; i < this.stateStack_.length; i++) {
+    var state = this.stateStack_[i];
+    graph.states.push({
+      id: 'state' + i,
+      type: state.node['type'],
+      start: state.node['start'],
+      end: state.node['end'],
+      scope: scopeId(state.scope),
+      value: describe(state.value, true),
+    });
+  }
+  return graph;
+};
+
+/**
+ * Convert a graph, as returned by Interpreter.Thread.prototype.graph,
+ * into DOT format (for rendering with, e.g., Graphviz).  Bold edges
+ * show the continuation (each state returns to the one below it on
+ * the stack); dashed edges go from each state to its scope.
+ * @param {{states: !Array<!Object>, scopes: !Array<!Object>,
+ *     objects: !Array<!Object>}} graph The graph to convert.
+ * @return {string} DOT source.
+ */
+Interpreter.Thread.graphToDot = function(graph) {
+  var lines = ['digraph thread {'];
+  // JSON string escapes are also valid in DOT quoted strings.
+  var quote = JSON.stringify;
+
+  /**
+   * Output a node with the given ID whose label lists the primitive
+   * entries in values; object-valued entries become edges.
+   * @param {string} id Node ID.
+   * @param {string} shape Node shape.
+   * @param {string} title First line of label.
+   * @param {?Object} values Entries to show, or null.
+   */
+  function node(id, shape, title, values) {
+    var label = [title];
+    for (var key in values) {
+      var value = values[key];
+      if (typeof value === 'string') {
+        label.push(key + ': ' + value);
+      } else {
+        lines.push('  ' + id + ' -> ' + value.ref + ' [label=' + quote(key) +
+            '];');
+      }
+    }
+    lines.push('  ' + id + ' [shape=' + shape + ', label=' +
+        quote(label.join('\n')) + '];');
+  }
+
+  for (var i = 0; i < graph.states.length; i++) {
+    var state = graph.states[i];
+    node(state.id, 'box', state.type + ' ' + state.start + '-' + state.end,
+        {value: state.value});
+    if (i > 0) {
+      lines.push('  ' + state.id + ' -> ' + graph.states[i - 1].id +
+          ' [style=bold];');
+    }
+    if (state.scope) {
+      lines.push('  ' + state.id + ' -> ' + state.scope + ' [style=dashed];');
+    }
+  }
+  for (var i = 0; i < graph.scopes.length; i++) {
+    var scope = graph.scopes[i];
+    node(scope.id, 'ellipse', scope.type + ' scope', scope.vars);
+    if (scope.outer) {
+      lines.push('  ' + scope.id + ' -> ' + scope.outer + ' [label="outer"];');
+    }
+  }
+  for (var i = 0; i < graph.objects.length; i++) {
+    var obj = graph.objects[i];
+    node(obj.id, 'note', obj.class, obj.properties);
+  }
+  lines.push('}');
+  return lines.join('\n') + '\n';
+};
+
 /**
  * Returns the permissions with which currently-executing code is
  * running (equivalent to a unix EUID, but in the form of a
@@ -4381,6 +5900,8 @@ Interpreter.prototype.installTypes = function() {
       throw new intrp.Error(perms, intrp.TYPE_ERROR,
           "Can't set prototype of non-extensible object");
     }
+    intrp.checkNotFrozen_(this, perms);
+    intrp.checkNotReadOnly_(this, perms);
     for (var p = proto; p !== null; p = p.proto) {
       if (p === this) {
         throw new intrp.Error(perms, intrp.TYPE_ERROR,
@@ -4402,7 +5923,7 @@ Interpreter.prototype.installTypes = function() {
   intrp.Object.prototype.isExtensible = function(perms) {
     if (perms === null) throw new TypeError("null can't check extensibility");
     // TODO(cpcallen:perms): add check for (object) readability.
-    return Object.isExtensible(this.properties);
+    return Object.isExtensible(this.properties) && !intrp.frozen_.has(this);
   };
 
   /**
@@ -4415,6 +5936,7 @@ Interpreter.prototype.installTypes = function() {
   intrp.Object.prototype.preventExtensions = function(perms) {
     if (perms === null) throw new TypeError("null can't prevent extensibions");
     // TODO(cpcallen:perms): add "controls"-type perm check.
+    intrp.checkNotReadOnly_(this, perms);
     Object.preventExtensions(this.properties);
     return true;
   };
@@ -4434,6 +5956,9 @@ Interpreter.prototype.installTypes = function() {
     }
     // TODO(cpcallen:perms): add check for (property) readability.
     var pd = Object.getOwnPropertyDescriptor(this.properties, key);
+    if (pd && intrp.frozen_.has(this)) {
+      pd.writable = pd.configurable = false;
+    }
     // TODO(cpcallen): can we eliminate this pointless busywork while
     // still maintaining type safety?
     return pd && new Descriptor(pd.writable, pd.enumerable, pd.configurable)
@@ -4454,12 +5979,17 @@ Interpreter.prototype.installTypes = function() {
     if (perms !== undefined) {
       if (perms === null) throw new TypeError("null can't defineProperty");
       // TODO(cpcallen:perms): add "controls"-type perm check.
+      intrp.checkNotFrozen_(this, perms);
+      intrp.checkNotReadOnly_(this, perms, key);
     }
     try {
       Object.defineProperty(this.properties, key, desc);
     } catch (e) {
       throw intrp.errorNativeToPseudo(e, perms || this.owner);
     }
+    if (intrp.observers_.size || intrp.hostObservers_.size) {
+      intrp.notifyObservers_(this, key, 'set');
+    }
   };
 
   /**
@@ -4501,12 +6031,21 @@ Interpreter.prototype.installTypes = function() {
   intrp.Object.prototype.set = function(key, value, perms) {
     if (perms === null) throw new TypeError("null can't set");
     // TODO(cpcallen:perms): add "controls"-type perm check.
+    intrp.checkNotFrozen_(this, perms);
+    // Overwriting a property with its existing value is harmless, even
+    // in read-only mode.
+    if (!Object.prototype.hasOwnProperty.call(this.properties, key) ||
+        !Object.is(this.properties[key], value)) {
+      intrp.checkNotReadOnly_(this, perms, key);
+    }
     try {
       this.properties[key] = value;
     } catch (e) {
       throw intrp.errorNativeToPseudo(e, perms);
     }
-
+    if (intrp.observers_.size || intrp.hostObservers_.size) {
+      intrp.notifyObservers_(this, key, 'set');
+    }
   };
 
   /**
@@ -4520,11 +6059,17 @@ Interpreter.prototype.installTypes = function() {
   intrp.Object.prototype.deleteProperty = function(key, perms) {
     if (perms === null) throw new TypeError("null can't delete");
     // TODO(cpcallen:perms): add "controls"-type perm check.
+    intrp.checkNotFrozen_(this, perms);
+    intrp.checkNotReadOnly_(this, perms, key);
+    var existed = Object.prototype.hasOwnProperty.call(this.properties, key);
     try {
       delete this.properties[key];
     } catch (e) {
       throw intrp.errorNativeToPseudo(e, perms);
     }
+    if (existed && (intrp.observers_.size || intrp.hostObservers_.size)) {
+      intrp.notifyObservers_(this, key, 'delete');
+    }
     return true;
   };
 
@@ -4896,8 +6441,13 @@ Interpreter.prototype.installTypes = function() {
     var info = state.info_;
     info.func = this.boundFunc;
     info.this = this.thisVal;
-    info.args = argList;
+    info.arguments = argList;
     info.construct = false;
+    info.toPrimitive = null;
+    // Let the Call step convert arguments to primitives if required.
+    if (intrp.argHints_(this.boundFunc, false)) {
+      return Interpreter.FunctionResult.CallAgain;
+    }
     // But just go and do the first .call directly.
     return this.boundFunc.call(intrp, thread, state, this.thisVal, argList);
   };
@@ -4926,8 +6476,13 @@ Interpreter.prototype.installTypes = function() {
     var info = state.info_;
     info.func = this.boundFunc;
     info.this = this.thisVal;
-    info.args = argList;
+    info.arguments = argList;
     info.construct = true;
+    info.toPrimitive = null;
+    // Let the Call step convert arguments to primitives if required.
+    if (intrp.argHints_(this.boundFunc, true)) {
+      return Interpreter.FunctionResult.CallAgain;
+    }
     // But just go and do the first .construct directly.
     return this.boundFunc.construct(intrp, thread, state, argList);
   };
@@ -4950,6 +6505,10 @@ Interpreter.prototype.installTypes = function() {
    * [[Construct]] specifications methods respectively.  If omitted,
    * the function will not be callable / constructable.
    *
+   * If options.argHints is supplied, any object arguments will be
+   * converted to primitives by ToPrimitive (with the given hint(s))
+   * before options.call is invoked.
+   *
    * The new object will be owned by options.owner (default:
    * intrp.ROOT), and have prototype options.proto (default:
    * intrp.FUNCTION - i.e., Function.prototype).
@@ -4987,6 +6546,9 @@ Interpreter.prototype.installTypes = function() {
       if (serialId && !('id' in this.call)) {
         this.call.id = serialId + ' [[Call]]';
       }
+      if (options.argHints) {
+        this.call.argHints = options.argHints;
+      }
     }
     if (options.construct) {
       this.construct = options.construct;
@@ -5246,36 +6808,8 @@ Interpreter.prototype.installTypes = function() {
     if (this.has('stack', intrp.ROOT)) {
       return;  // Do not overwrite existing .stack
     }
-    var stack = [];
-    for (var i = 0; i < callers.length; i++) {
-      var /** string */ line = '    ';
-      var frame = callers[i];
-      if ('func' in frame) {
-        var /** !Interpreter.prototype.Function */ func = frame.func;
-        var /** string */ name;
-        try {
-          var pd = func.getOwnPropertyDescriptor('name', perms);
-          if (pd) {
-            name = String(pd.value);
-          } else {
-            name = 'anonymous function';
-          }
-        } catch (e) {
-          name = 'unreadable function';
-        }
-      } else if ('eval' in frame) {
-        name = '"' + frame.eval + '"';
-      } else if ('program' in frame) {
-        name = '"' + frame.program + '"';
-      }
-      if ('line' in frame) {
-        line += 'at ' + name + ' ' + frame.line + ':' + frame.col;
-      } else {
-        line += 'in ' + name;
-      }
-      stack.push(line);
-    }
-    this.defineProperty('stack', Descriptor.wc.withValue(stack.join('\n')));
+    this.defineProperty('stack',
+        Descriptor.wc.withValue(intrp.formatCallers(callers, perms)));
   };
 
   /**
@@ -5573,8 +7107,7 @@ Interpreter.prototype.installTypes = function() {
         // this thread?  Note that this will typically be root, and
         // .onConnect will therefore get caller perms === root, which
         // is probably dangerous.  Here and several places below.
-        intrp.createThreadForFuncCall(
-            server.owner, func, obj, [], undefined, server.timeLimit);
+        intrp.createThreadForNetwork_(server, func, obj, []);
       }
 
       // Handle incoming data from clients.  N.B. that data is a
@@ -5583,9 +7116,7 @@ Interpreter.prototype.installTypes = function() {
       socket.on('data', function(data) {
         var func = obj.get('onReceive', this.owner);
         if (func instanceof intrp.Function && server.owner !== null) {
-          intrp.createThreadForFuncCall(
-              server.owner, func, obj, [String(data)],
-              undefined, server.timeLimit);
+          intrp.createThreadForNetwork_(server, func, obj, [String(data)]);
         }
       });
 
@@ -5593,21 +7124,29 @@ Interpreter.prototype.installTypes = function() {
         intrp.log('net', 'Connection from %s closed.', socket.remoteAddress);
         var func = obj.get('onEnd', this.owner);
         if (func instanceof intrp.Function && server.owner !== null) {
-          intrp.createThreadForFuncCall(
-              server.owner, func, obj, [], undefined, server.timeLimit);
+          intrp.createThreadForNetwork_(server, func, obj, []);
         }
         // TODO(cpcallen): Don't fully close half-closed connection yet.
         socket.end();
       });
 
+      socket.on('close', function() {
+        // Release the (now dead) socket so that connection objects
+        // retained by the database do not keep it alive, then give
+        // the connection object a chance to clean up.
+        obj.socket = undefined;
+        var func = obj.get('onClose', this.owner);
+        if (func instanceof intrp.Function && server.owner !== null) {
+          intrp.createThreadForNetwork_(server, func, obj, []);
+        }
+      });
+
       socket.on('error', function(error) {
         intrp.log('net', 'Socket error:', error);
         var func = obj.get('onError', this.owner);
         if (func instanceof intrp.Function && server.owner !== null) {
           var userError = intrp.errorNativeToPseudo(error, server.owner);
-          intrp.createThreadForFuncCall(
-              server.owner, func, obj, [userError],
-              undefined, server.timeLimit);
+          intrp.createThreadForNetwork_(server, func, obj, [userError]);
         }
       });
 
@@ -5661,6 +7200,7 @@ Interpreter.prototype.installTypes = function() {
  *            id: (string|undefined),
  *            call: (Interpreter.NativeCallImpl|undefined),
  *            construct: (Interpreter.NativeConstructImpl|undefined),
+ *            argHints: (!Interpreter.ToPrimitiveHint|undefined),
  *            owner: (?Interpreter.Owner|undefined),
  *            proto: (?Interpreter.prototype.Object|undefined)}}
  */
@@ -5956,6 +7496,15 @@ stepFuncs_['AssignmentExpression'] = function(thread, stack, state, node) {
   // state.step_ === 2: Got operand(s); do assignment.
   var rightValue = state.value;
   var value = state.tmp_;
+  var op = node['operator'].slice(0, -1);  // E.g. '+=' -> '+'.
+  if (state.info_ ||
+      (op && this.needsToPrimitive_(op, value, rightValue))) {
+    var operands = this.toPrimitiveOperands_(state, [value, rightValue],
+        op === '+' ? 'default' : 'number');
+    if (operands instanceof Interpreter.State) return operands;
+    value = operands[0];
+    rightValue = operands[1];
+  }
   switch (node['operator']) {
     // Regular assignment is special due to function naming & destructuring.
     case '=':
@@ -6013,8 +7562,18 @@ stepFuncs_['BinaryExpression'] = function(thread, stack, state, node) {
   // state.step_ === 2: Got operands; do binary operation.
   var leftValue = state.tmp_;
   var rightValue = state.value;
+  var op = node['operator'];
+  if (state.info_ || this.needsToPrimitive_(op, leftValue, rightValue)) {
+    var hint = (op === 'in') ? ['string'] :
+        (op === '+' || op === '==' || op === '!=') ? 'default' : 'number';
+    var operands =
+        this.toPrimitiveOperands_(state, [leftValue, rightValue], hint);
+    if (operands instanceof Interpreter.State) return operands;
+    leftValue = operands[0];
+    rightValue = operands[1];
+  }
   var /** ?Interpreter.Value */ value;
-  switch (node['operator']) {
+  switch (op) {
     case '==':  value = leftValue ==  rightValue; break;
     case '!=':  value = leftValue !=  rightValue; break;
     case '===': value = leftValue === rightValue; break;
@@ -6097,13 +7656,16 @@ stepFuncs_['BreakStatement'] = function(thread, stack, state, node) {
  * - directEval: is this a direct call to the global eval function?
  * - construct: is this a [[Construct]] call (rather than default [[Call]])?
  * - funcState: place for NativeFunction impls to save additional state info.
+ * - toPrimitive: progress converting arguments to primitives, for
+ *   native functions with argument hints.
  * TODO(cpcallen): give funcState a narrower type.
  * @typedef {{func: ?Interpreter.prototype.Function,
  *            this: ?Interpreter.Value,
  *            arguments: !Array<?Interpreter.Value>,
  *            directEval: boolean,
  *            construct: boolean,
- *            funcState: *}}
+ *            funcState: *,
+ *            toPrimitive: (?Interpreter.ToPrimitiveInfo|undefined)}}
  */
 Interpreter.CallInfo;
 
@@ -6145,7 +7707,8 @@ stepFuncs_['CallExpression'] = function(thread, stack, state, node) {
                 arguments: [],
                 directEval: false,
                 construct: state.node['type'] === 'NewExpression',
-                funcState: undefined};
+                funcState: undefined,
+                toPrimitive: null};
     if (state.ref) {  // Callee was MemberExpression or Identifier.
       state.tmp_ = this.getValue(state.ref, state.scope.perms);
       if (state.ref[0] instanceof Interpreter.Scope) {
@@ -6236,6 +7799,21 @@ stepFuncs_['Call'] = function(thread, stack, state, node) {
         throw e;
       }
     }
+    // Convert arguments to primitives first, if the function requires.
+    var hint = this.argHints_(func, state.info_.construct);
+    if (hint) {
+      var info = state.info_.toPrimitive;
+      if (!info) {
+        info = {operands: args.slice(), n: 0, tried: 0};
+        state.info_.toPrimitive = info;
+      }
+      var next = this.toPrimitive_(state, info, hint);
+      if (next) {
+        state.step_ = 0;
+        return next;
+      }
+      args = state.info_.arguments = info.operands;
+    }
     var r =
         state.info_.construct ?
         func.construct(this, thread, state, args) :
@@ -6246,6 +7824,8 @@ stepFuncs_['Call'] = function(thread, stack, state, node) {
           return;
         case Interpreter.FunctionResult.Block:
           thread.status = Interpreter.Thread.Status.BLOCKED;
+          thread.blockedSince = this.now();
+          thread.blockedOn = func;
           return;
         case Interpreter.FunctionResult.CallAgain:
           state.step_ = 0;
@@ -6686,8 +8266,18 @@ stepFuncs_['MemberExpression'] = function(thread, stack, state, node) {
         "Can't convert " + base + ' to Object');
   }
   // Step 9: propertyKey = ToPropertyKey(propertyNameValue).
-  var /** string */ key =
-      node['computed'] ? String(state.value) : node['property']['name'];
+  var /** string */ key;
+  if (node['computed']) {
+    var value = state.value;
+    if (state.info_ || value instanceof this.Object) {
+      var operands = this.toPrimitiveOperands_(state, [value], 'string');
+      if (operands instanceof Interpreter.State) return operands;
+      value = operands[0];
+    }
+    key = String(value);
+  } else {
+    key = node['property']['name'];
+  }
   stack.pop();  // Must be after last throw new this.Error...
   if (state.wantRef_) {
     stack[stack.length - 1].ref = [base, key];
@@ -6960,6 +8550,13 @@ stepFuncs_['UnaryExpression'] = function(thread, stack, state, node) {
     return new Interpreter.State(node['argument'], state.scope, wr);
   }
   var value = state.value;
+  var op = node['operator'];
+  if ((op === '-' || op === '+' || op === '~') &&
+      (state.info_ || value instanceof this.Object)) {
+    var operands = this.toPrimitiveOperands_(state, [value], 'number');
+    if (operands instanceof Interpreter.State) return operands;
+    value = operands[0];
+  }
   if (node['operator'] === '-') {
     value = -value;
   } else if (node['operator'] === '+') {
@@ -7014,7 +8611,16 @@ stepFuncs_['UpdateExpression'] = function(thread, stack, state, node) {
     return new Interpreter.State(node['argument'], state.scope, true);
   }
   if (!state.ref) throw new TypeError('argument not an LVALUE??');
-  var value = Number(this.getValue(state.ref, state.scope.perms));
+  // Only get the old value once, even if converting it to a
+  // primitive requires calling .valueOf and/or .toString.
+  var value = state.info_ ? undefined :
+      this.getValue(state.ref, state.scope.perms);
+  if (state.info_ || value instanceof this.Object) {
+    var operands = this.toPrimitiveOperands_(state, [value], 'number');
+    if (operands instanceof Interpreter.State) return operands;
+    value = operands[0];
+  }
+  value = Number(value);
   var prefix = Boolean(node['prefix']);
   var /** ?Interpreter.Value */ rval;
   if (node['operator'] === '++') {
@@ -7095,6 +8701,8 @@ for (var name in stepFuncs_) {
 
 exports = module.exports = Interpreter;
 
+exports.SERIALIZATION_VERSION = SERIALIZATION_VERSION;
+
 exports.testOnly = {
   getBoundNames: getBoundNames,
   hasArgumentsOrEval: hasArgumentsOrEval,
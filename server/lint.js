@@ -0,0 +1,291 @@
+/**
+ * @license
+ * Copyright 2020 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/**
+ * @fileoverview A simple linter for JavaScript code, to warn about
+ *     likely mistakes before code is installed in the database.
+ * @author cpcallen@google.com (Christopher Allen)
+ */
+'use strict';
+
+var Parser = require('./parser').Parser;
+
+/**
+ * A warning produced by the linter.  Line and column numbers are
+ * 1-based.
+ * @typedef {{line: number, col: number, message: string}}
+ */
+var Warning;
+
+/**
+ * Information about a variable declared in a function (or catch) scope.
+ * @typedef {{pos: number, used: boolean, reportUnused: boolean}}
+ */
+var VarInfo;
+
+/**
+ * A scope, as tracked by the linter.  vars is null for the global
+ * scope, since globals are never reported as unused and whether a
+ * name is a global is instead determined by the isGlobal callback
+ * (and by declarations in the program being linted).
+ * @typedef {{vars: ?Map<string, !VarInfo>, outer: ?Object}}
+ */
+var LintScope;
+
+/**
+ * Parse src, which may be either a program or a single expression
+ * (e.g. an anonymous function expression, as found in the editor).
+ * @param {string} src Source code.
+ * @return {!Object} Root AST node.
+ */
+var parse = function(src) {
+  try {
+    return Parser.parse(src);
+  } catch (e) {
+    try {
+      var node = Parser.parseExpressionAt(src, 0);
+    } catch (e2) {
+      throw e;
+    }
+    if (src.slice(node['end']).trim().replace(/;$/, '') !== '') throw e;
+    return node;
+  }
+};
+
+/**
+ * Check JavaScript source code for some common mistakes:
+ *
+ * - Variables and functions declared but never used.
+ * - Use of names which are neither declared nor known globals.
+ * - Assignment used as a condition.
+ * - Non-empty switch cases which fall through to the next case.
+ *
+ * @param {string} src Source code of a program or expression.
+ * @param {function(string): boolean=} isGlobal Function returning
+ *     true iff the given name is a global variable.  If omitted,
+ *     uses of undeclared names are not reported.
+ * @return {!Array<!Warning>} List of warnings, in source order.
+ * @throws {SyntaxError} If src cannot be parsed.
+ */
+var lint = function(src, isGlobal) {
+  var ast = parse(src);
+  var warnings = [];
+  var globals = new Set();
+
+  /**
+   * @param {number} pos Character offset in src.
+   * @param {string} message Warning message.
+   */
+  function warn(pos, message) {
+    var before = src.slice(0, pos);
+    var line = before.split('\n').length;
+    var col = pos - before.lastIndexOf('\n');
+    warnings.push({line: line, col: col, message: message});
+  }
+
+  /**
+   * Hoist var and function declarations found in node (but not in
+   * nested functions) into scope.
+   * @param {*} node AST node or other value to search.
+   * @param {!LintScope} scope Scope to declare names in.
+   */
+  function declare(node, scope) {
+    if (!node || typeof node !== 'object') return;
+    if (Array.isArray(node)) {
+      for (var i = 0; i < node.length; i++) declare(node[i], scope);
+      return;
+    }
+    switch (node['type']) {
+      case 'VariableDeclarator':
+        addVar(scope, node['id'], true);
+        declare(node['init'], scope);
+        return;
+      case 'FunctionDeclaration':
+        addVar(scope, node['id'], true);
+        return;
+      case 'FunctionExpression':
+        return;
+    }
+    for (var key in node) {
+      if (key !== 'type') declare(node[key], scope);
+    }
+  }
+
+  /**
+   * @param {!LintScope} scope Scope to add variable to.
+   * @param {!Object} id Identifier node.
+   * @param {boolean} reportUnused Warn if variable is never used?
+   */
+  function addVar(scope, id, reportUnused) {
+    var name = id['name'];
+    if (!scope.vars) {
+      globals.add(name);
+    } else if (!scope.vars.has(name)) {
+      scope.vars.set(name,
+          {pos: id['start'], used: false, reportUnused: reportUnused});
+    }
+  }
+
+  /**
+   * Record a reference to name, reporting it if undeclared.
+   * @param {!Object} id Identifier node.
+   * @param {!LintScope} scope Scope in which reference appears.
+   * @param {boolean} isRead Is this reference a read (rather than
+   *     only a write)?
+   */
+  function reference(id, scope, isRead) {
+    var name = id['name'];
+    for (var s = scope; s; s = s.outer) {
+      if (s.vars && s.vars.has(name)) {
+        if (isRead) s.vars.get(name).used = true;
+        return;
+      }
+    }
+    if (globals.has(name) || !isGlobal || isGlobal(name)) return;
+    warn(id['start'], "'" + name + "' is not declared");
+  }
+
+  /**
+   * Report unused variables in scope.
+   * @param {!LintScope} scope Scope being exited.
+   */
+  function checkUnused(scope) {
+    scope.vars.forEach(function(info, name) {
+      if (info.reportUnused && !info.used) {
+        warn(info.pos, "'" + name + "' is declared but never used");
+      }
+    });
+  }
+
+  /**
+   * Warn if node (the test of a conditional) is an assignment.
+   * @param {?Object} test Test expression node, or null.
+   */
+  function checkCondition(test) {
+    if (test && test['type'] === 'AssignmentExpression' &&
+        test['operator'] === '=') {
+      warn(test['start'], "Assignment in condition; did you mean '==='?");
+    }
+  }
+
+  /**
+   * @param {!Object} node Function node.
+   * @param {!LintScope} outer Enclosing scope.
+   */
+  function visitFunction(node, outer) {
+    if (node['type'] === 'FunctionExpression' && node['id']) {
+      // Named function expressions bind their own name.
+      outer = {vars: new Map(), outer: outer};
+      addVar(outer, node['id'], false);
+    }
+    var scope = {vars: new Map(), outer: outer};
+    scope.vars.set('arguments', {pos: 0, used: false, reportUnused: false});
+    var params = node['params'];
+    for (var i = 0; i < params.length; i++) {
+      addVar(scope, params[i], false);
+    }
+    declare(node['body'], scope);
+    visit(node['body'], scope);
+    checkUnused(scope);
+  }
+
+  /**
+   * Visit node and its subtrees.
+   * @param {*} node AST node or other value to visit.
+   * @param {!LintScope} scope Current scope.
+   */
+  function visit(node, scope) {
+    if (!node || typeof node !== 'object') return;
+    if (Array.isArray(node)) {
+      for (var i = 0; i < node.length; i++) visit(node[i], scope);
+      return;
+    }
+    switch (node['type']) {
+      case 'Identifier':
+        reference(node, scope, true);
+        return;
+      case 'FunctionDeclaration':
+      case 'FunctionExpression':
+        visitFunction(node, scope);
+        return;
+      case 'VariableDeclarator':
+        visit(node['init'], scope);
+        return;
+      case 'MemberExpression':
+        visit(node['object'], scope);
+        if (node['computed']) visit(node['property'], scope);
+        return;
+      case 'Property':
+        visit(node['value'], scope);
+        return;
+      case 'LabeledStatement':
+        visit(node['body'], scope);
+        return;
+      case 'BreakStatement':
+      case 'ContinueStatement':
+        return;
+      case 'AssignmentExpression':
+        if (node['left']['type'] === 'Identifier') {
+          // Compound assignments (e.g. +=) also read the variable.
+          reference(node['left'], scope, node['operator'] !== '=');
+        } else {
+          visit(node['left'], scope);
+        }
+        visit(node['right'], scope);
+        return;
+      case 'CatchClause':
+        var catchScope = {vars: new Map(), outer: scope};
+        addVar(catchScope, node['param'], false);
+        visit(node['body'], catchScope);
+        return;
+      case 'IfStatement':
+      case 'WhileStatement':
+      case 'DoWhileStatement':
+      case 'ForStatement':
+      case 'ConditionalExpression':
+        checkCondition(node['test']);
+        break;
+      case 'SwitchStatement':
+        var cases = node['cases'];
+        for (var i = 0; i < cases.length - 1; i++) {
+          var body = cases[i]['consequent'];
+          if (!body.length) continue;  // Deliberate grouping of cases.
+          var last = body[body.length - 1]['type'];
+          if (last !== 'BreakStatement' && last !== 'ReturnStatement' &&
+              last !== 'ThrowStatement' && last !== 'ContinueStatement') {
+            warn(cases[i + 1]['start'], 'Fall-through from previous case');
+          }
+        }
+        break;
+    }
+    for (var key in node) {
+      if (key !== 'type') visit(node[key], scope);
+    }
+  }
+
+  var global = {vars: null, outer: null};
+  declare(ast, global);
+  visit(ast, global);
+  warnings.sort(function(a, b) {return a.line - b.line || a.col - b.col;});
+  return warnings;
+};
+
+///////////////////////////////////////////////////////////////////////////////
+// Exports
+///////////////////////////////////////////////////////////////////////////////
+
+exports.lint = lint;
@@ -256,6 +256,10 @@ Serializer.serialize = function(intrp) {
     'hrStartTime_',
     'previousTime_',
     'runner_',
+    'hostObservers_',
+    'readOnly_',
+    'readOnlyWritableKeys_',
+    'slowTasks_',
     'Object',
     'Function',
     'UserFunction',
@@ -403,6 +407,142 @@ Serializer.serialize = function(intrp) {
   return json;
 };
 
+/**
+ * Migration functions for serialized interpreters.  The function at
+ * key N takes a JSON-compatible flatpack (as produced by .serialize)
+ * of serialization version N and modifies it in place so that it
+ * becomes a valid flatpack of version N + 1.
+ *
+ * Whenever SERIALIZATION_VERSION (in interpreter.js) is incremented,
+ * a migration from the previous version should be added here, so that
+ * existing .city files can be upgraded (via the migrate tool) rather
+ * than being stranded.
+ * @const {!Object<number, function(!Array<!Object>)>}
+ */
+Serializer.migrations = {};
+
+/**
+ * Version 2 added the .subscriptions_ property to Interpreter
+ * instances.
+ * @param {!Array<!Object>} json Flatpack to migrate.
+ */
+Serializer.migrations[1] = function(json) {
+  var props = json[0]['props'];
+  if (!props['subscriptions_']) {
+    props['subscriptions_'] = {'#': json.length};
+    json.push({'#': json.length, 'type': 'Map'});
+  }
+};
+
+/**
+ * Version 3 added the .maintenanceMessage property to Interpreter
+ * instances.
+ * @param {!Array<!Object>} json Flatpack to migrate.
+ */
+Serializer.migrations[2] = function(json) {
+  var props = json[0]['props'];
+  if (!('maintenanceMessage' in props)) {
+    props['maintenanceMessage'] = null;
+  }
+};
+
+/**
+ * Version 4 added the .blockedSince, .blockedOn and .blockedReported
+ * properties to Thread instances.
+ * @param {!Array<!Object>} json Flatpack to migrate.
+ */
+Serializer.migrations[3] = function(json) {
+  for (var i = 0; i < json.length; i++) {
+    if (json[i]['type'] !== 'Thread') continue;
+    var props = json[i]['props'];
+    if (!('blockedSince' in props)) props['blockedSince'] = 0;
+    if (!('blockedOn' in props)) props['blockedOn'] = null;
+    if (!('blockedReported' in props)) props['blockedReported'] = false;
+  }
+};
+
+/**
+ * Version 5 added the .observers_ property to Interpreter instances.
+ * @param {!Array<!Object>} json Flatpack to migrate.
+ */
+Serializer.migrations[4] = function(json) {
+  var props = json[0]['props'];
+  if (!props['observers_']) {
+    props['observers_'] = {'#': json.length};
+    json.push({'#': json.length, 'type': 'IterableWeakMap'});
+  }
+};
+
+/**
+ * Version 6 added the .network property to Thread instances.
+ * @param {!Array<!Object>} json Flatpack to migrate.
+ */
+Serializer.migrations[5] = function(json) {
+  for (var i = 0; i < json.length; i++) {
+    if (json[i]['type'] !== 'Thread') continue;
+    var props = json[i]['props'];
+    if (!('network' in props)) props['network'] = false;
+  }
+};
+
+/**
+ * Version 7 added the .frozen_ property to Interpreter instances.
+ * @param {!Array<!Object>} json Flatpack to migrate.
+ */
+Serializer.migrations[6] = function(json) {
+  var props = json[0]['props'];
+  if (!props['frozen_']) {
+    props['frozen_'] = {'#': json.length};
+    json.push({'#': json.length, 'type': 'IterableWeakSet'});
+  }
+};
+
+/**
+ * Get the serialization version of a flatpack.
+ * @param {!Array<!Object>} json JSON-compatible object, as produced by
+ *     .serialize.
+ * @return {number|undefined} The serialization version, or undefined
+ *     if the flatpack predates versioning.
+ */
+Serializer.getVersion = function(json) {
+  var props = json[0] && json[0]['props'];
+  var version = props && props['serializationVersion'];
+  return (typeof version === 'number') ? version : undefined;
+};
+
+/**
+ * Upgrade (in place) a flatpack produced by an older version of the
+ * serializer so that it can be deserialized by the current version,
+ * by applying each of the required migrations in turn.
+ * @param {!Array<!Object>} json JSON-compatible object, as produced by
+ *     .serialize.
+ * @return {number} The number of migrations applied.
+ */
+Serializer.migrate = function(json) {
+  if (!Array.isArray(json)) {
+    throw new TypeError('Top-level JSON is not a list.');
+  }
+  var version = Serializer.getVersion(json);
+  if (version === undefined) {
+    throw new Error('version error: serialized interpreter is unversioned');
+  } else if (version > Interpreter.SERIALIZATION_VERSION) {
+    throw new Error('version error: serialized interpreter was version ' +
+        version + ', which is newer than current version ' +
+        Interpreter.SERIALIZATION_VERSION);
+  }
+  var count = 0;
+  for (; version < Interpreter.SERIALIZATION_VERSION; version++, count++) {
+    var migration = Serializer.migrations[version];
+    if (!migration) {
+      throw new Error('version error: no migration from version ' +
+          version + ' to version ' + (version + 1));
+    }
+    migration(json);
+    json[0]['props']['serializationVersion'] = version + 1;
+  }
+  return count;
+};
+
 /**
  * Recursively search node to find all non-primitives.
  *
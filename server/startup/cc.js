@@ -33,6 +33,41 @@ CC.root = new 'CC.root';
 var perms = new 'perms';
 var setPerms = new 'setPerms';
 
+///////////////////////////////////////////////////////////////////////////////
+// Publish/subscribe API.
+//
+CC.subscribe = new 'CC.subscribe';
+CC.unsubscribe = new 'CC.unsubscribe';
+CC.publish = new 'CC.publish';
+
+///////////////////////////////////////////////////////////////////////////////
+// Property change observation API.
+//
+CC.observe = new 'CC.observe';
+CC.unobserve = new 'CC.unobserve';
+
+///////////////////////////////////////////////////////////////////////////////
+// Value encoding API.
+//
+CC.encode = new 'CC.encode';
+CC.decode = new 'CC.decode';
+
+///////////////////////////////////////////////////////////////////////////////
+// Maintenance mode API.
+//
+CC.setMaintenance = new 'CC.setMaintenance';
+CC.getMaintenance = new 'CC.getMaintenance';
+CC.slowTasks = new 'CC.slowTasks';
+CC.blockedThreads = new 'CC.blockedThreads';
+
+///////////////////////////////////////////////////////////////////////////////
+// Code checking, testing and editing API.
+//
+CC.lint = new 'CC.lint';
+CC.format = new 'CC.format';
+CC.deepEqual = new 'CC.deepEqual';
+CC.patchFunction = new 'CC.patchFunction';
+
 ///////////////////////////////////////////////////////////////////////////////
 // Networking API.
 //
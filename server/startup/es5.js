@@ -146,7 +146,7 @@ Object.defineProperty(Object, 'defineProperty', {enumerable: false});
       'setUTCMinutes', 'setUTCMonth', 'setUTCSeconds', 'setYear',
       'toDateString', 'toISOString', 'toJSON', 'toGMTString', 'toTimeString',
       'toUTCString', 'toLocaleDateString', 'toLocaleString',
-      'toLocaleTimeString']],
+      'toLocaleTimeString', 'valueOf']],
     [RegExp, 'RegExp',
      [],
      ['toString', 'test', 'exec']],
@@ -328,7 +328,7 @@ Object.isFrozen = function isFrozen(obj) {
     // Assume no accessor properties.
     if (pd.configurable || pd.writable) return false;
   }
-  if (Object.isExtensible) return false;
+  if (Object.isExtensible(obj)) return false;
   return true;
 };
 Object.defineProperty(Object, 'isFrozen', {enumerable: false});
@@ -345,7 +345,7 @@ Object.isSealed = function isSealed(obj) {
     var pd = Object.getOwnPropertyDescriptor(obj, key);
     if (pd.configurable) return false;
   }
-  if (Object.isExtensible) return false;
+  if (Object.isExtensible(obj)) return false;
   return true;
 };
 Object.defineProperty(Object, 'isSealed', {enumerable: false});
@@ -362,7 +362,7 @@ Object.freeze = function freeze(obj) {
     // Assume no accessor properties.
     Object.defineProperty(obj, key, {writable: false, configurable: false});
   }
-  Object.preventExtensions(obj);
+  return Object.preventExtensions(obj);
 };
 Object.defineProperty(Object, 'freeze', {enumerable: false});
 
@@ -378,7 +378,7 @@ Object.seal = function seal(obj) {
     // Assume no accessor properties.
     Object.defineProperty(obj, key, {configurable: false});
   }
-  Object.preventExtensions(obj);
+  return Object.preventExtensions(obj);
 };
 Object.defineProperty(Object, 'seal', {enumerable: false});
 
@@ -590,9 +590,9 @@ Array.prototype.some = function(callback/*, thisArg*/) {
 Object.defineProperty(Array.prototype, 'some', {enumerable: false});
 
 Array.prototype.sort = function sort(comparefn) {
-  // Polylfill adapted from:
+  // Polylfill originally adapted from:
   // https://github.com/v8/v8/blob/8e43b9c01d60ddb5e58ec8de9d34616ea1bb905f/src/js/array.js
-  // TODO(cpcallen): as of ES2020, Array.prototype.sort must be stable.
+  // but now using a (stable, as required by ES2020) merge sort.
   var obj = this;
   // Let obj = ToObject(this)
   if (typeof(obj) !== 'object' && typeof(obj) !== 'function' || obj === null) {
@@ -614,6 +614,14 @@ Array.prototype.sort = function sort(comparefn) {
   } else if(typeof(comparefn) !== 'function') {
     throw new TypeError(
         'The comparison function must be either a function or undefined');
+  } else {
+    // Per the SortCompare spec function, the result of comparefn is
+    // converted to a number, with NaN being treated as +0.
+    var userfn = comparefn;
+    comparefn = function(x, y) {
+      var v = Number(userfn(x, y));
+      return isNaN(v) ? 0 : v;
+    };
   }
 
   if (len < 2) return obj;
@@ -628,45 +636,47 @@ Array.prototype.sort = function sort(comparefn) {
   // properties.  Newer versions of V8 don't seem to do this any more,
   // so for simplicity we sort only own properties.
   //
-  // We do this by first moving all non-undefined properties to the
-  // front of the array and move the undefineds after that.  This
-  // moves holes to the end.
+  // We do this by first copying all non-undefined properties into a
+  // separate array and sorting that.  Only once the sort has
+  // completed are they written back to the front of obj, followed by
+  // the undefineds.  This moves holes to the end, and means that an
+  // exception thrown by comparefn leaves obj unmodified.
   //
   // TODO(cpcallen): this is slow.  Do it (as V8 did, before moving to
   // a torque-based implementation) using a native function.
+  var defined = [];
   var undefCount = 0;
-  for (var i = 0, j = 0; j < len; j++) {
+  for (var j = 0; j < len; j++) {
     if (Object.prototype.hasOwnProperty.call(obj, j)) {
       if (obj[j] === undefined) {
         undefCount++;
       } else {
-        obj[i++] = obj[j];
+        defined[defined.length] = obj[j];
       }
     }
   }
-  var definedCount = i;
+  var sorted = Array.prototype.sort.mergeSort_(defined, comparefn);
+  for (var i = 0; i < sorted.length; i++) {
+    obj[i] = sorted[i];
+  }
   for (; undefCount; undefCount--) {
     obj[i++] = undefined;
   }
   for (; i < len; i++) {
     delete obj[i];
   }
-      
-  Array.prototype.sort.quicksort_(obj, 0, definedCount, comparefn);
   return obj;
 };
 Object.defineProperty(Array.prototype, 'sort', {enumerable: false});
 
 // Helper functions.
-Array.prototype.sort.insertionSort_ = function insertionSort_(
-    a, from, to, comparefn) {
+Array.prototype.sort.insertionSort_ = function insertionSort_(a, comparefn) {
   // For short (length <= 10) arrays, insertion sort is used for efficiency.
-  for (var i = from + 1; i < to; i++) {
+  for (var i = 1; i < a.length; i++) {
     var element = a[i];
-    for (var j = i - 1; j >= from; j--) {
+    for (var j = i - 1; j >= 0; j--) {
       var tmp = a[j];
-      var order = comparefn(tmp, element);
-      if (order > 0) {
+      if (comparefn(tmp, element) > 0) {
         a[j + 1] = tmp;
       } else {
         break;
@@ -674,119 +684,36 @@ Array.prototype.sort.insertionSort_ = function insertionSort_(
     }
     a[j + 1] = element;
   }
+  return a;
 };
 Object.defineProperty(Array.prototype.sort, 'insertionSort_',
                       {enumerable: false});
 
-Array.prototype.sort.getThirdIndex_ = function getThirdIndex_(
-    a, from, to, comparefn) {
-  var t_array = [];
-  // Use both 'from' and 'to' to determine the pivot candidates.
-  var increment = 200 + ((to - from) & 15);
-  var j = 0;
-  from += 1;
-  to -= 1;
-  for (var i = from; i < to; i += increment) {
-    t_array[j] = [i, a[i]];
-    j++;
-  }
-  t_array.sort(function(a, b) {
-    return comparefn(a[1], b[1]);
-  });
-  var third_index = t_array[t_array.length >> 1][0];
-  return third_index;
-};
-Object.defineProperty(Array.prototype.sort, 'getThirdIndex_',
-                      {enumerable: false});
-
-Array.prototype.sort.quicksort_ = function quicksort_(a, from, to, comparefn) {
-  /* In-place QuickSort algorithm.
+Array.prototype.sort.mergeSort_ = function mergeSort_(a, comparefn) {
+  /* Stable merge sort.  Returns a sorted array, which may or may not
+   * be a.
    */
-  var third_index = 0;
-  while (true) {
-    // Insertion sort is faster for short arrays.
-    if (to - from <= 10) {
-      Array.prototype.sort.insertionSort_(a, from, to, comparefn);
-      return;
-    }
-    if (to - from > 1000) {
-      third_index = Array.prototype.sort.getThirdIndex_(a, from, to, comparefn);
-    } else {
-      third_index = from + ((to - from) >> 1);
-    }
-    // Find a pivot as the median of first, last and middle element.
-    var v0 = a[from];
-    var v1 = a[to - 1];
-    var v2 = a[third_index];
-    var c01 = comparefn(v0, v1);
-    if (c01 > 0) {
-      // v1 < v0, so swap them.
-      var tmp = v0;
-      v0 = v1;
-      v1 = tmp;
-    } // v0 <= v1.
-    var c02 = comparefn(v0, v2);
-    if (c02 >= 0) {
-      // v2 <= v0 <= v1.
-      var tmp = v0;
-      v0 = v2;
-      v2 = v1;
-      v1 = tmp;
-    } else {
-      // v0 <= v1 && v0 < v2
-      var c12 = comparefn(v1, v2);
-      if (c12 > 0) {
-        // v0 <= v2 < v1
-        var tmp = v1;
-        v1 = v2;
-        v2 = tmp;
-      }
-    }
-    // v0 <= v1 <= v2
-    a[from] = v0;
-    a[to - 1] = v2;
-    var pivot = v1;
-    var low_end = from + 1;   // Upper bound of elements lower than pivot.
-    var high_start = to - 1;  // Lower bound of elements greater than pivot.
-    a[third_index] = a[low_end];
-    a[low_end] = pivot;
-
-    // From low_end to i are elements equal to pivot.
-    // From i to high_start are elements that haven't been compared yet.
-    partition: for (var i = low_end + 1; i < high_start; i++) {
-      var element = a[i];
-      var order = comparefn(element, pivot);
-      if (order < 0) {
-        a[i] = a[low_end];
-        a[low_end] = element;
-        low_end++;
-      } else if (order > 0) {
-        do {
-          high_start--;
-          if (high_start == i) break partition;
-          var top_elem = a[high_start];
-          order = comparefn(top_elem, pivot);
-        } while (order > 0);
-        a[i] = a[high_start];
-        a[high_start] = element;
-        if (order < 0) {
-          element = a[i];
-          a[i] = a[low_end];
-          a[low_end] = element;
-          low_end++;
-        }
-      }
-    }
-    if (to - high_start < low_end - from) {
-      quicksort_(a, high_start, to, comparefn);
-      to = low_end;
+  if (a.length <= 10) {
+    return Array.prototype.sort.insertionSort_(a, comparefn);
+  }
+  var mid = a.length >> 1;
+  var left = mergeSort_(a.slice(0, mid), comparefn);
+  var right = mergeSort_(a.slice(mid), comparefn);
+  var result = [];
+  var i = 0, j = 0;
+  while (i < left.length && j < right.length) {
+    // Take from left unless right is strictly smaller, for stability.
+    if (comparefn(left[i], right[j]) > 0) {
+      result[result.length] = right[j++];
     } else {
-      quicksort_(a, from, low_end, comparefn);
-      from = high_start;
+      result[result.length] = left[i++];
     }
   }
+  while (i < left.length) result[result.length] = left[i++];
+  while (j < right.length) result[result.length] = right[j++];
+  return result;
 };
-Object.defineProperty(Array.prototype.sort, 'quicksort_', {enumerable: false});
+Object.defineProperty(Array.prototype.sort, 'mergeSort_', {enumerable: false});
 
 Array.prototype.toLocaleString = function() {
   var out = [];
@@ -815,8 +742,12 @@ String.prototype.replace = function replace(substr, newSubstr) {
   var str = this;
   if (substr instanceof RegExp) {  // string.replace(regexp, function)
     var subs = [];
+    if (substr.global) substr.lastIndex = 0;
     var m = substr.exec(str);
     while (m) {
+      if (substr.global && m[0] === '') {
+        substr.lastIndex++;  // Avoid looping forever on empty matches.
+      }
       m.push(m.index, str);
       var inject = newSubstr.apply(undefined, m);
       subs.push([m.index, m[0].length, inject]);
@@ -86,6 +86,32 @@ var WeakMap = new 'WeakMap';
   }
 })();
 
+///////////////////////////////////////////////////////////////////////////////
+// Object constructor polyfills
+///////////////////////////////////////////////////////////////////////////////
+
+Object.assign = function assign(target, varArgs) {
+  // Polyfill adapted from:
+  // developer.mozilla.org/docs/Web/JavaScript/Reference/Global_Objects/Object/assign
+  if (target === null || target === undefined) {
+    throw new TypeError('Cannot convert undefined or null to object');
+  }
+  var to = Object(target);
+  for (var i = 1; i < arguments.length; i++) {
+    var source = arguments[i];
+    if (source === null || source === undefined) continue;
+    var from = Object(source);
+    for (var key in from) {
+      // for-in visits only enumerable properties, as required.
+      if (Object.prototype.hasOwnProperty.call(from, key)) {
+        to[key] = from[key];
+      }
+    }
+  }
+  return to;
+};
+Object.defineProperty(Object, 'assign', {enumerable: false});
+
 ///////////////////////////////////////////////////////////////////////////////
 // Array constructor polyfills
 ///////////////////////////////////////////////////////////////////////////////
@@ -304,3 +330,226 @@ Object.defineProperty(Number, 'MIN_SAFE_INTEGER',
      enumerable: false,
      writable: false,
      value: -Number.MAX_SAFE_INTEGER});
+
+///////////////////////////////////////////////////////////////////////////////
+// Map and Set polyfills
+///////////////////////////////////////////////////////////////////////////////
+// Maps and Sets are ordinary objects that keep their contents in
+// (non-enumerable) arrays, so they are serialized and dumped like any
+// other object.  Keys are compared using SameValueZero, by linear
+// search.  Lacking iterators, contents can only be enumerated with
+// .forEach; lacking accessor properties, .size is a read-only data
+// property updated by each mutating method.
+
+var Map = function Map(/* entries */) {
+  if (!(this instanceof Map)) {
+    throw new TypeError("Constructor Map requires 'new'");
+  }
+  Object.defineProperty(this, 'keys_', {writable: true, value: []});
+  Object.defineProperty(this, 'values_', {writable: true, value: []});
+  Object.defineProperty(this, 'size', {configurable: true, value: 0});
+  var entries = arguments[0];
+  if (entries !== undefined && entries !== null) {
+    for (var i = 0; i < entries.length; i++) {
+      this.set(entries[i][0], entries[i][1]);
+    }
+  }
+};
+
+Map.prototype.clear = function clear() {
+  if (!(this instanceof Map) || !Array.isArray(this.keys_)) {
+    throw new TypeError('Map.prototype.clear called on incompatible receiver');
+  }
+  this.keys_ = [];
+  this.values_ = [];
+  Object.defineProperty(this, 'size', {value: 0});
+};
+Object.defineProperty(Map.prototype, 'clear', {enumerable: false});
+
+Map.prototype.delete = function(key) {
+  if (!(this instanceof Map) || !Array.isArray(this.keys_)) {
+    throw new TypeError('Map.prototype.delete called on incompatible receiver');
+  }
+  var i = (key === key) ?
+      this.keys_.indexOf(key) : this.keys_.findIndex(Number.isNaN);
+  if (i === -1) return false;
+  this.keys_.splice(i, 1);
+  this.values_.splice(i, 1);
+  Object.defineProperty(this, 'size', {value: this.keys_.length});
+  return true;
+};
+Object.defineProperty(Map.prototype, 'delete', {enumerable: false});
+
+Map.prototype.forEach = function forEach(callback/*, thisArg*/) {
+  if (!(this instanceof Map) || !Array.isArray(this.keys_)) {
+    throw new TypeError(
+        'Map.prototype.forEach called on incompatible receiver');
+  } else if (typeof callback !== 'function') {
+    throw new TypeError(callback + ' is not a function');
+  }
+  // Iterate over a snapshot of the keys, skipping any deleted by
+  // callback.  (Unlike ES6, entries added by callback are not visited.)
+  var keys = this.keys_.slice();
+  for (var i = 0; i < keys.length; i++) {
+    if (this.has(keys[i])) {
+      callback.call(arguments[1], this.get(keys[i]), keys[i], this);
+    }
+  }
+};
+Object.defineProperty(Map.prototype, 'forEach', {enumerable: false});
+
+Map.prototype.get = function get(key) {
+  if (!(this instanceof Map) || !Array.isArray(this.keys_)) {
+    throw new TypeError('Map.prototype.get called on incompatible receiver');
+  }
+  var i = (key === key) ?
+      this.keys_.indexOf(key) : this.keys_.findIndex(Number.isNaN);
+  return (i === -1) ? undefined : this.values_[i];
+};
+Object.defineProperty(Map.prototype, 'get', {enumerable: false});
+
+Map.prototype.has = function has(key) {
+  if (!(this instanceof Map) || !Array.isArray(this.keys_)) {
+    throw new TypeError('Map.prototype.has called on incompatible receiver');
+  }
+  return (key === key) ? this.keys_.indexOf(key) !== -1 :
+      this.keys_.findIndex(Number.isNaN) !== -1;
+};
+Object.defineProperty(Map.prototype, 'has', {enumerable: false});
+
+Map.prototype.set = function set(key, value) {
+  if (!(this instanceof Map) || !Array.isArray(this.keys_)) {
+    throw new TypeError('Map.prototype.set called on incompatible receiver');
+  }
+  var i = (key === key) ?
+      this.keys_.indexOf(key) : this.keys_.findIndex(Number.isNaN);
+  if (i === -1) {
+    this.keys_.push(key === 0 ? 0 : key);  // Normalise -0 to +0.
+    this.values_.push(value);
+    Object.defineProperty(this, 'size', {value: this.keys_.length});
+  } else {
+    this.values_[i] = value;
+  }
+  return this;
+};
+Object.defineProperty(Map.prototype, 'set', {enumerable: false});
+
+var Set = function Set(/* values */) {
+  if (!(this instanceof Set)) {
+    throw new TypeError("Constructor Set requires 'new'");
+  }
+  Object.defineProperty(this, 'values_', {writable: true, value: []});
+  Object.defineProperty(this, 'size', {configurable: true, value: 0});
+  var values = arguments[0];
+  if (values !== undefined && values !== null) {
+    for (var i = 0; i < values.length; i++) {
+      this.add(values[i]);
+    }
+  }
+};
+
+Set.prototype.add = function add(value) {
+  if (!(this instanceof Set) || !Array.isArray(this.values_)) {
+    throw new TypeError('Set.prototype.add called on incompatible receiver');
+  }
+  if (!this.has(value)) {
+    this.values_.push(value === 0 ? 0 : value);  // Normalise -0 to +0.
+    Object.defineProperty(this, 'size', {value: this.values_.length});
+  }
+  return this;
+};
+Object.defineProperty(Set.prototype, 'add', {enumerable: false});
+
+Set.prototype.clear = function clear() {
+  if (!(this instanceof Set) || !Array.isArray(this.values_)) {
+    throw new TypeError('Set.prototype.clear called on incompatible receiver');
+  }
+  this.values_ = [];
+  Object.defineProperty(this, 'size', {value: 0});
+};
+Object.defineProperty(Set.prototype, 'clear', {enumerable: false});
+
+Set.prototype.delete = function(value) {
+  if (!(this instanceof Set) || !Array.isArray(this.values_)) {
+    throw new TypeError('Set.prototype.delete called on incompatible receiver');
+  }
+  var i = (value === value) ?
+      this.values_.indexOf(value) : this.values_.findIndex(Number.isNaN);
+  if (i === -1) return false;
+  this.values_.splice(i, 1);
+  Object.defineProperty(this, 'size', {value: this.values_.length});
+  return true;
+};
+Object.defineProperty(Set.prototype, 'delete', {enumerable: false});
+
+Set.prototype.forEach = function forEach(callback/*, thisArg*/) {
+  if (!(this instanceof Set) || !Array.isArray(this.values_)) {
+    throw new TypeError(
+        'Set.prototype.forEach called on incompatible receiver');
+  } else if (typeof callback !== 'function') {
+    throw new TypeError(callback + ' is not a function');
+  }
+  // Iterate over a snapshot of the values, skipping any deleted by
+  // callback.  (Unlike ES6, values added by callback are not visited.)
+  var values = this.values_.slice();
+  for (var i = 0; i < values.length; i++) {
+    if (this.has(values[i])) {
+      callback.call(arguments[1], values[i], values[i], this);
+    }
+  }
+};
+Object.defineProperty(Set.prototype, 'forEach', {enumerable: false});
+
+Set.prototype.has = function has(value) {
+  if (!(this instanceof Set) || !Array.isArray(this.values_)) {
+    throw new TypeError('Set.prototype.has called on incompatible receiver');
+  }
+  return (value === value) ? this.values_.indexOf(value) !== -1 :
+      this.values_.findIndex(Number.isNaN) !== -1;
+};
+Object.defineProperty(Set.prototype, 'has', {enumerable: false});
+
+var WeakSet = function WeakSet(/* values */) {
+  // Implemented using a WeakMap, so (like WeakMap) entries do not keep
+  // their keys alive, and are not preserved in dumps.
+  if (!(this instanceof WeakSet)) {
+    throw new TypeError("Constructor WeakSet requires 'new'");
+  }
+  Object.defineProperty(this, 'map_', {value: new WeakMap});
+  var values = arguments[0];
+  if (values !== undefined && values !== null) {
+    for (var i = 0; i < values.length; i++) {
+      this.add(values[i]);
+    }
+  }
+};
+
+WeakSet.prototype.add = function add(value) {
+  if (!(this instanceof WeakSet) || !(this.map_ instanceof WeakMap)) {
+    throw new TypeError(
+        'WeakSet.prototype.add called on incompatible receiver');
+  }
+  this.map_.set(value, true);
+  return this;
+};
+Object.defineProperty(WeakSet.prototype, 'add', {enumerable: false});
+
+WeakSet.prototype.delete = function(value) {
+  if (!(this instanceof WeakSet) || !(this.map_ instanceof WeakMap)) {
+    throw new TypeError(
+        'WeakSet.prototype.delete called on incompatible receiver');
+  }
+  return (typeof value === 'object' && value !== null ||
+      typeof value === 'function') && this.map_.delete(value);
+};
+Object.defineProperty(WeakSet.prototype, 'delete', {enumerable: false});
+
+WeakSet.prototype.has = function has(value) {
+  if (!(this instanceof WeakSet) || !(this.map_ instanceof WeakMap)) {
+    throw new TypeError(
+        'WeakSet.prototype.has called on incompatible receiver');
+  }
+  return (typeof value === 'object' && value !== null ||
+      typeof value === 'function') && this.map_.has(value);
+};
+Object.defineProperty(WeakSet.prototype, 'has', {enumerable: false});
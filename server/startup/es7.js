@@ -27,3 +27,37 @@
 
 Array.prototype.includes = new 'Array.prototype.includes';
 Object.defineProperty(Array.prototype, 'includes', {enumerable: false});
+
+///////////////////////////////////////////////////////////////////////////////
+// Object constructor polyfills (from ES2017, but too useful to omit)
+///////////////////////////////////////////////////////////////////////////////
+
+Object.entries = function entries(obj) {
+  if (obj === null || obj === undefined) {
+    throw new TypeError('Cannot convert undefined or null to object');
+  }
+  var o = Object(obj);
+  var result = [];
+  for (var key in o) {
+    if (Object.prototype.hasOwnProperty.call(o, key)) {
+      result.push([key, o[key]]);
+    }
+  }
+  return result;
+};
+Object.defineProperty(Object, 'entries', {enumerable: false});
+
+Object.values = function values(obj) {
+  if (obj === null || obj === undefined) {
+    throw new TypeError('Cannot convert undefined or null to object');
+  }
+  var o = Object(obj);
+  var result = [];
+  for (var key in o) {
+    if (Object.prototype.hasOwnProperty.call(o, key)) {
+      result.push(o[key]);
+    }
+  }
+  return result;
+};
+Object.defineProperty(Object, 'values', {enumerable: false});
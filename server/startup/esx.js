@@ -67,6 +67,7 @@ var PermissionError = new 'PermissionError';
   //     [Object, 'Object', [static methods], [instance methods]]
 
   var struct = [
+    [Error, 'Error', ['captureStackTrace'], []],
     [Object, 'Object', ['getOwnerOf', 'setOwnerOf'], []],
     [Thread, 'Thread',
      ['current', 'kill', 'suspend', 'callers'],
@@ -211,3 +212,220 @@ var clearTimeout = function(thread) {
   }
   Thread.kill(thread);
 };
+
+var setInterval = function(func, delay) {
+  /* setInterval(func, delay[, ...args]) -> thread
+   *
+   * Arguments:
+   *   func <Function>: A function to call each time the timer elapses.
+   *   delay <number>: Time to wait (in ms) between calls to func.
+   *   ...args <any>: Optional arguments to pass to func.
+   *
+   * Returns:
+   *   <Thread>, which may be passed to clearInterval() to cancel.
+   *
+   * All calls to func are made from the returned thread, which
+   * suspends between calls.  If func throws, no further calls are made.
+   */
+  // TODO(cpcallen:perms): setPerms(callerPerms());
+  var args = Array.prototype.slice.call(arguments, 2);
+  return setTimeout(function() {
+    var thread = Thread.current();
+    while (true) {
+      func.apply(undefined, args);
+      if (clearInterval.cleared_.has(thread)) return;
+      suspend(delay);
+    }
+  }, delay);
+};
+
+var clearInterval = function(thread) {
+  /* clearInterval(thread)
+   *
+   * Arguments:
+   *   thread <Thread>: The Thread object returned by setInterval().
+   *
+   * Unlike clearTimeout(), this may be called from func itself, in
+   * which case no further calls will be made once func returns.
+   * Non-Thread values are silently ignored.
+   */
+  // TODO(cpcallen:perms): setPerms(callerPerms());
+  if (!(thread instanceof Thread)) {
+    return;
+  } else if (thread === Thread.current()) {
+    clearInterval.cleared_.add(thread);
+    return;
+  }
+  Thread.kill(thread);
+};
+Object.defineProperty(clearInterval, 'cleared_', {value: new WeakSet});
+
+///////////////////////////////////////////////////////////////////////////////
+// Promise.  Roughly conformant with ES6, except that reactions (then
+// and catch callbacks) are run by new threads started via setTimeout,
+// rather than from a microtask queue, and .all accepts only arrays
+// (or array-likes), since we have no iterators.
+//
+var Promise = function Promise(executor) {
+  if (!(this instanceof Promise)) {
+    throw new TypeError("Constructor Promise requires 'new'");
+  } else if (typeof executor !== 'function') {
+    throw new TypeError('Promise resolver ' + executor + ' is not a function');
+  }
+  Object.defineProperty(this, 'state_', {writable: true, value: 'pending'});
+  Object.defineProperty(this, 'value_', {writable: true, value: undefined});
+  Object.defineProperty(this, 'reactions_', {writable: true, value: []});
+  var promise = this;
+  var done = false;
+  try {
+    executor(function(value) {
+      if (done) return;
+      done = true;
+      Promise.resolve_(promise, value);
+    }, function(reason) {
+      if (done) return;
+      done = true;
+      Promise.settle_(promise, 'rejected', reason);
+    });
+  } catch (e) {
+    if (!done) {
+      done = true;
+      Promise.settle_(promise, 'rejected', e);
+    }
+  }
+};
+
+Promise.resolve_ = function(promise, value) {
+  // Resolve promise with value, adopting its state if it is thenable.
+  if (value === promise) {
+    Promise.settle_(promise, 'rejected',
+        new TypeError('Chaining cycle detected for promise'));
+    return;
+  }
+  if (value !== null &&
+      (typeof value === 'object' || typeof value === 'function')) {
+    try {
+      var then = value.then;
+    } catch (e) {
+      Promise.settle_(promise, 'rejected', e);
+      return;
+    }
+    if (typeof then === 'function') {
+      var called = false;
+      setTimeout(function() {
+        try {
+          then.call(value, function(v) {
+            if (called) return;
+            called = true;
+            Promise.resolve_(promise, v);
+          }, function(r) {
+            if (called) return;
+            called = true;
+            Promise.settle_(promise, 'rejected', r);
+          });
+        } catch (e) {
+          if (!called) {
+            called = true;
+            Promise.settle_(promise, 'rejected', e);
+          }
+        }
+      }, 0);
+      return;
+    }
+  }
+  Promise.settle_(promise, 'fulfilled', value);
+};
+Object.defineProperty(Promise, 'resolve_', {enumerable: false});
+
+Promise.settle_ = function(promise, state, value) {
+  // Fulfill or reject promise, and schedule any pending reactions.
+  if (promise.state_ !== 'pending') return;
+  promise.state_ = state;
+  promise.value_ = value;
+  var reactions = promise.reactions_;
+  promise.reactions_ = [];
+  for (var i = 0; i < reactions.length; i++) {
+    Promise.react_(promise, reactions[i]);
+  }
+};
+Object.defineProperty(Promise, 'settle_', {enumerable: false});
+
+Promise.react_ = function(promise, reaction) {
+  // Start a thread to call the appropriate handler of reaction with
+  // the value of (settled) promise, and resolve or reject the promise
+  // returned by the .then call that created reaction.
+  setTimeout(function() {
+    var fulfilled = (promise.state_ === 'fulfilled');
+    var handler = fulfilled ? reaction.onFulfilled : reaction.onRejected;
+    if (typeof handler !== 'function') {
+      (fulfilled ? reaction.resolve : reaction.reject)(promise.value_);
+      return;
+    }
+    try {
+      var result = handler(promise.value_);
+    } catch (e) {
+      reaction.reject(e);
+      return;
+    }
+    reaction.resolve(result);
+  }, 0);
+};
+Object.defineProperty(Promise, 'react_', {enumerable: false});
+
+Promise.all = function all(promises) {
+  return new Promise(function(resolve, reject) {
+    var results = [];
+    var remaining = promises.length;
+    if (!remaining) {
+      resolve(results);
+      return;
+    }
+    var fulfill = function(i, value) {
+      results[i] = value;
+      if (--remaining === 0) resolve(results);
+    };
+    for (var i = 0; i < promises.length; i++) {
+      Promise.resolve(promises[i]).then(fulfill.bind(undefined, i), reject);
+    }
+  });
+};
+Object.defineProperty(Promise, 'all', {enumerable: false});
+
+Promise.reject = function reject(reason) {
+  return new Promise(function(resolve, reject) {
+    reject(reason);
+  });
+};
+Object.defineProperty(Promise, 'reject', {enumerable: false});
+
+Promise.resolve = function resolve(value) {
+  if (value instanceof Promise) return value;
+  return new Promise(function(resolve, reject) {
+    resolve(value);
+  });
+};
+Object.defineProperty(Promise, 'resolve', {enumerable: false});
+
+Promise.prototype.catch = function(onRejected) {
+  return this.then(undefined, onRejected);
+};
+Object.defineProperty(Promise.prototype, 'catch', {enumerable: false});
+
+Promise.prototype.then = function then(onFulfilled, onRejected) {
+  if (!(this instanceof Promise) || !Array.isArray(this.reactions_)) {
+    throw new TypeError(
+        'Promise.prototype.then called on incompatible receiver');
+  }
+  var reaction = {onFulfilled: onFulfilled, onRejected: onRejected};
+  var derived = new Promise(function(resolve, reject) {
+    reaction.resolve = resolve;
+    reaction.reject = reject;
+  });
+  if (this.state_ === 'pending') {
+    this.reactions_.push(reaction);
+  } else {
+    Promise.react_(this, reaction);
+  }
+  return derived;
+};
+Object.defineProperty(Promise.prototype, 'then', {enumerable: false});
@@ -0,0 +1,122 @@
+/**
+ * @license
+ * Copyright 2020 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/**
+ * @fileoverview Tests for the Code City server's configuration handling.
+ */
+'use strict';
+
+const CodeCity = require('../codecity');
+const fs = require('fs');
+const {getInterpreter} = require('./interpreter_common');
+const os = require('os');
+const path = require('path');
+const {T} = require('./testing');
+
+/**
+ * Set up CodeCity to use a temporary configuration file containing
+ * config, as if it had been loaded from that file at startup.
+ * @param {!Object} config Initial configuration.
+ * @return {function(!Object)} Function which replaces the contents
+ *     of the configuration file.
+ */
+function setupConfig(config) {
+  const file = path.join(fs.mkdtempSync(path.join(os.tmpdir(), 'cc-')),
+                       'test.cfg');
+  const write = function(config) {
+    // A negative interval prevents applyConfig from starting the
+    // checkpoint timer.
+    config = Object.assign({checkpointInterval: -1}, config);
+    fs.writeFileSync(file, JSON.stringify(config));
+    return config;
+  };
+  CodeCity.configFile = file;
+  CodeCity.fileConfig_ = write(config);
+  CodeCity.config = Object.assign({}, CodeCity.fileConfig_);
+  CodeCity.interpreter = getInterpreter();
+  CodeCity.applyConfig();
+  return write;
+}
+
+/**
+ * Tests for CodeCity.reloadConfig.
+ * @param {!T} t The test runner object.
+ */
+exports.testReloadConfig = function(t) {
+  const name = 'reloadConfig';
+  const tz = process.env.TZ;
+  try {
+    const write = setupConfig({slowTaskMs: 100, noLog: ['net'], dbDir: 'a'});
+    const options = CodeCity.interpreter.options;
+    t.expect(name + ': initial slowTaskMs', options.slowTaskMs, 100);
+
+    // Change one reloadable option and one which requires a restart.
+    write({slowTaskMs: 200, noLog: ['net'], dbDir: 'b'});
+    let report = CodeCity.reloadConfig();
+    t.expect(name + ': changed slowTaskMs', options.slowTaskMs, 200);
+    t.assert(name + ': reports change', /Changed: slowTaskMs\./.test(report),
+        report);
+    t.assert(name + ': reports restart required',
+        /restart required\): dbDir\./.test(report), report);
+    t.expect(name + ': dbDir unchanged', CodeCity.config.dbDir, 'a');
+
+    // Remove reloadable options: they should revert to their defaults.
+    write({dbDir: 'a', timezone: 'Asia/Tokyo'});
+    report = CodeCity.reloadConfig();
+    t.assert(name + ': reports removal',
+        /Changed: slowTaskMs, noLog, timezone\./.test(report), report);
+    t.expect(name + ': removed slowTaskMs', options.slowTaskMs, undefined);
+    t.expect(name + ': removed noLog', options.noLog, undefined);
+    t.expect(name + ': added timezone', process.env.TZ, 'Asia/Tokyo');
+
+    write({dbDir: 'a'});
+    CodeCity.reloadConfig();
+    t.expect(name + ': removed timezone', process.env.TZ, tz);
+  } finally {
+    if (tz === undefined) {
+      delete process.env.TZ;
+    } else {
+      process.env.TZ = tz;
+    }
+  }
+};
+
+/**
+ * Tests for the timezone configuration option.
+ * @param {!T} t The test runner object.
+ */
+exports.testTimezone = function(t) {
+  const name = 'timezone';
+  const tz = process.env.TZ;
+  try {
+    setupConfig({timezone: 'Asia/Tokyo'});  // UTC+9, without DST.
+    const intrp = CodeCity.interpreter;
+    const src = `
+        var d = new Date(Date.UTC(2020, 0, 1, 20));
+        [d.getDate(), d.getHours(), d.getTimezoneOffset()].join();
+    `;
+    const thread = intrp.createThreadForSrc(src).thread;
+    intrp.run();
+    t.expect(name + ': local time', thread.value, '2,5,-540');
+  } finally {
+    if (tz === undefined) {
+      delete process.env.TZ;
+    } else {
+      process.env.TZ = tz;
+    }
+  }
+};
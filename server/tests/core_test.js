@@ -0,0 +1,310 @@
+/**
+ * @license
+ * Copyright 2020 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/**
+ * @fileoverview Tests for library code in the core database.
+ */
+'use strict';
+
+const CodeCity = require('../codecity');
+const fs = require('fs');
+const Interpreter = require('../interpreter');
+const path = require('path');
+const {T} = require('./testing');
+const util = require('util');
+
+/**
+ * Directory containing the core database.
+ * @const {string}
+ */
+const coreDir = path.join(__dirname, '../../core');
+
+/**
+ * Run a test of the core database: src is evaluated in a new
+ * Interpreter instance into which the core files (except
+ * core_99_startup.js, which starts the servers) have been loaded.
+ * (Simulated) time will be automatically fast-forwarded as required
+ * to wake sleeping threads.
+ * @param {!T} t The test runner object.
+ * @param {string} name The name of the test.
+ * @param {string} src The code to be evaled.
+ * @param {number|string|boolean|null|undefined} expected The expected
+ *     completion value.
+ * @param {function(!Interpreter)=} onLoad Optional callback to be
+ *     called once the core files have been loaded, before src is run.
+ */
+function runCoreTest(t, name, src, expected, onLoad) {
+  const intrp = CodeCity.makeInterpreter();
+  let thread;
+  try {
+    const filenames = fs.readdirSync(coreDir).filter(function(filename) {
+      return /^core_.*\.js$/.test(filename) &&
+          filename !== 'core_99_startup.js';
+    }).sort();
+    for (const filename of filenames) {
+      intrp.createThreadForSrc(
+          fs.readFileSync(path.join(coreDir, filename), 'utf8'));
+      intrp.run();
+    }
+    if (onLoad) onLoad(intrp);
+    thread = intrp.createThreadForSrc(src).thread;
+    let runResult;
+    while ((runResult = intrp.run())) {
+      if (runResult < 0) break;  // Blocked thread(s); give up.
+      // Fast forward to wake-up time.  Cast to defeat @private check.
+      /** @type {?} */(intrp).previousTime_ += runResult - intrp.now();
+    }
+  } catch (e) {
+    t.crash(name, util.format('%s\n%s', src, e.stack));
+    return;
+  } finally {
+    intrp.stop();
+  }
+  const r = intrp.pseudoToNative(thread.value);
+  t.expect(name, r, expected, src);
+}
+
+/**
+ * Tests for $.utils.code.exportOwned.
+ * @param {!T} t The test runner object.
+ */
+exports.testExportOwned = function(t) {
+  const src = `
+    $.exportOwner = {};
+    $.exported = {plain: 1};
+    Object.setOwnerOf($.exported, $.exportOwner);
+    Object.defineProperty($.exported, 'hidden',
+        {value: 2, writable: true, enumerable: false, configurable: true});
+    Object.defineProperty($.exported, 'fixed',
+        {value: 3, writable: false, enumerable: true, configurable: false});
+    $.exported.child = {};
+    Object.setOwnerOf($.exported.child, $.exportOwner);
+    Object.defineProperty($.exported, 'child', {enumerable: false});
+
+    var src = $.utils.code.exportOwned($.exportOwner);
+    delete $.exported;
+    eval(src);
+    var e = $.exported;
+    var hidden = Object.getOwnPropertyDescriptor(e, 'hidden');
+    var fixed = Object.getOwnPropertyDescriptor(e, 'fixed');
+    var child = Object.getOwnPropertyDescriptor(e, 'child');
+    [src.includes('$.exported.plain = 1;'),
+     e.plain, Object.getOwnerOf(e) === $.exportOwner,
+     hidden.value, hidden.enumerable,
+     fixed.value, fixed.writable, fixed.configurable,
+     typeof child.value, child.enumerable,
+     Object.getOwnerOf(child.value) === $.exportOwner].join();
+  `;
+  runCoreTest(t, 'exportOwned', src,
+      'true,1,true,2,false,3,false,false,object,false,true');
+};
+
+/**
+ * Tests for $.utils.code.patch, .history and .revert.
+ * @param {!T} t The test runner object.
+ */
+exports.testEditHistory = function(t) {
+  const src = `
+    var obj = {};
+    obj.f = function() {return 1;};
+    var f = obj.f;
+    var user = {};
+    $.utils.code.patch(f, function() {return 2;}, user);
+    $.utils.code.patch(f, function() {return 3;});
+    var h = $.utils.code.history(f);
+    var r = [obj.f === f, f(), h.length, h[0].value === f, h[1].value(),
+             h[2].value(), h[1].user === user, h[0].user, typeof h[0].time];
+
+    // Revert to the previous version, then back past it to the first.
+    var restored = $.utils.code.revert(obj, 'f');
+    r.push(restored === f, obj.f === f, f(), $.utils.code.history(f).length,
+           f.lastModifiedUser === user);
+    $.utils.code.revert(obj, 'f');
+    r.push(f(), $.utils.code.history(f).length);
+    try {
+      $.utils.code.revert(obj, 'f');
+    } catch (e) {
+      r.push(e.name);
+    }
+
+    // Values which can't be patched are replaced instead.
+    obj.g = Math.max;
+    var g = function() {};
+    $.utils.code.recordEdit(obj.g, g);
+    obj.g = g;
+    r.push($.utils.code.patch(Math.max, g), $.utils.code.revert(obj, 'g') ===
+           Math.max, obj.g === Math.max);
+    r.join();
+  `;
+  runCoreTest(t, 'patch/history/revert', src,
+      'true,3,3,true,2,1,true,,number,true,true,2,2,true,1,1,RangeError,' +
+      'false,true,true');
+};
+
+/**
+ * Tests for saving a function with the inline editor.
+ * @param {!T} t The test runner object.
+ */
+exports.testInlineEditPatch = function(t) {
+  const src = `
+    var obj = {};
+    obj.f = (function() {
+      var n = 0;
+      return function() {return ++n;};
+    })();
+    var f = obj.f;
+    f.verb = 'count';
+    f();
+    $.http['code.']['/inlineEdit'].save(obj, 'f',
+        'function() {return n += 10;}');
+    [obj.f === f, f(), f.verb, typeof f.previousVersion,
+     f.previousVersion()].join();
+  `;
+  runCoreTest(t, 'inlineEdit patches function', src,
+      'true,11,count,function,12');
+};
+
+/**
+ * Tests for $.utils.object.deepCopy.
+ * @param {!T} t The test runner object.
+ */
+exports.testDeepCopy = function(t) {
+  const src = `
+    var foreign = {};
+    Object.setOwnerOf(foreign, {});
+    var proto = {};
+    var a = Object.create(proto);
+    a.self = a;
+    a.list = [1, {back: a}];
+    a.date = new Date(5);
+    a.foreign = foreign;
+    a.method = function() {};
+    Object.defineProperty(a, 'hidden',
+        {value: {x: 1}, writable: false, enumerable: false});
+    Object.preventExtensions(a.list[1]);
+
+    var copied = 0;
+    var c = $.utils.object.deepCopy(a, {onCopy: function() {copied++;}});
+    var hidden = Object.getOwnPropertyDescriptor(c, 'hidden');
+    [c !== a, Object.getPrototypeOf(c) === proto,
+     // Cycles are preserved within the copy.
+     c.self === c, c.list !== a.list, c.list[0], c.list[1].back === c,
+     Object.isExtensible(c.list[1]),
+     c.date !== a.date, c.date.getTime(),
+     // Functions and other users' objects are shared.
+     c.foreign === foreign, c.method === a.method,
+     hidden.value !== a.hidden, hidden.value.x, hidden.writable,
+     hidden.enumerable, copied].join();
+  `;
+  runCoreTest(t, 'deepCopy', src,
+      'true,true,true,true,1,true,false,true,5,true,true,true,1,false,false,5');
+};
+
+/**
+ * Tests for expiry of disconnected guest users.
+ * @param {!T} t The test runner object.
+ */
+exports.testGuestExpiry = function(t) {
+  const src = `
+    var room = Object.create($.room);
+    var guest = $.servers.telnet.createGuest();
+    guest.moveTo(room);
+    var conn = Object.create($.servers.telnet.connection);
+    conn.user = guest;
+    guest.connection = conn;
+    conn.onEnd();
+    var start = Date.now();
+    suspend(1000);  // Let onDisconnect run.
+    var r = [$.user.isPrototypeOf(guest), guest.location === room];
+    suspend($.servers.telnet.GUEST_TIMEOUT_MS - (Date.now() - start) - 1000);
+    r.push($.user.isPrototypeOf(guest));
+    suspend(2000);
+    r.push($.user.isPrototypeOf(guest), room.getContents().length);
+    r.join();
+  `;
+  runCoreTest(t, 'guest expiry', src, 'true,true,true,false,0');
+};
+
+/**
+ * Tests for the telnet server's handling of maintenance mode.
+ * @param {!T} t The test runner object.
+ */
+exports.testTelnetMaintenance = function(t) {
+  const src = `
+    var out = [];
+    var write = function(text) {out.push(text);};
+    var admin = $.servers.telnet.createUser();
+    $.userDatabase.set('ab', admin);
+    $.system.admins.push(admin);
+    var user = $.servers.telnet.createUser();
+    $.userDatabase.set('cd', user);
+
+    $.system.setMaintenance('Closed');
+    var conn = Object.create($.servers.telnet.connection);
+    conn.write = write;
+    conn.onReceiveLine('identify as guest');
+    conn.onReceiveLine('identify as cd');
+    var r = [out.length, /Closed/.test(out[0]), conn.user];
+    conn.user = user;
+    conn.onReceiveLine('look');
+    r.push(out.length);
+
+    // Admins can log in, and can end maintenance mode.
+    out = [];
+    var adminConn = Object.create($.servers.telnet.connection);
+    adminConn.write = write;
+    adminConn.onReceiveLine('identify as ab');
+    adminConn.onReceiveLine(';$.system.setMaintenance(null)');
+    r.push(adminConn.user === admin, $.system.getMaintenance(),
+           /Closed/.test(out.join()));
+    r.join();
+  `;
+  runCoreTest(t, 'telnet maintenance', src, '2,true,,3,true,,false');
+};
+
+/**
+ * Tests for logging in to a database in read-only mode.
+ * @param {!T} t The test runner object.
+ */
+exports.testReadOnlyLogin = function(t) {
+  const setup = `
+    $.roUser = $.servers.telnet.createUser();
+    $.roUser.moveTo($.startRoom);
+    $.userDatabase.set('ef', $.roUser);
+  `;
+  const src = `
+    var conn = Object.create($.servers.telnet.connection);
+    conn.write = function(text) {};
+    conn.onReceiveLine('identify as ef');
+    var r = [conn.user === $.roUser, $.roUser.connection === conn];
+    conn.onEnd();
+    r.push($.roUser.connection, typeof $.roUser.disconnectTime);
+    // The world itself remains read-only.
+    try {
+      $.roUser.description = 'Changed';
+    } catch (e) {
+      r.push(e.name);
+    }
+    r.join();
+  `;
+  runCoreTest(t, 'login in read-only mode', src, 'true,true,,number,TypeError',
+      (intrp) => {
+        intrp.createThreadForSrc(setup);
+        intrp.run();
+        CodeCity.makeReadOnly(intrp);
+      });
+};
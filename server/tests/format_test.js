@@ -0,0 +1,68 @@
+/**
+ * @license
+ * Copyright 2020 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/**
+ * @fileoverview Tests for the code formatter.
+ * @author cpcallen@google.com (Christopher Allen)
+ */
+'use strict';
+
+const {format} = require('../format');
+const {T} = require('./testing');
+
+/**
+ * Unit tests for format.
+ * @param {!T} t The test runner object.
+ */
+exports.testFormat = function(t) {
+  const cases = [
+    // Blocks, trailing whitespace and blank lines.
+    ['if (x) {  \n      foo();\n\n}', 'if (x) {\n  foo();\n\n}'],
+    // Brackets opened on the same line share a level.
+    ['foo(function() {\nbar();\n});', 'foo(function() {\n  bar();\n});'],
+    // Wrapped arguments and conditions.
+    ['function f(a,\nb) {\nif (a &&\nb) {\nreturn;\n}\n}',
+     'function f(a,\n    b) {\n  if (a &&\n      b) {\n    return;\n  }\n}'],
+    // Continuation lines.
+    ['var s = 1 +\n2;\nvar t;', 'var s = 1 +\n    2;\nvar t;'],
+    // Switch statements.
+    ['switch (x) {\ncase 1:\nfoo();\nbreak;\ndefault:\n}',
+     'switch (x) {\n  case 1:\n    foo();\n    break;\n  default:\n}'],
+    // Multi-line comments keep their shape; strings are untouched.
+    ['{\n/* A\n * b.\n */\n}', '{\n  /* A\n   * b.\n   */\n}'],
+    ["{\nx = 'a\\\n   b';\n}", "{\n  x = 'a\\\n   b';\n}"],
+    // Brackets in strings, comments and regexps are ignored.
+    ["{\nx = '{' + /[(]/; // )\ny();\n}",
+     "{\n  x = '{' + /[(]/; // )\n  y();\n}"],
+  ];
+  for (const [src, expected] of cases) {
+    const name = 'format(' + JSON.stringify(src) + ')';
+    const formatted = format(src);
+    t.expect(name, formatted, expected);
+    t.expect(name + ' is idempotent', format(formatted), formatted);
+  }
+
+  t.expect("format(..., '\\t')", format('{\nx;\n}', '\t'), '{\n\tx;\n}');
+  t.expect('format(..., 4)', format('{\nx;\n}', 4), '{\n    x;\n}');
+
+  try {
+    format('"unterminated');
+    t.fail('format syntax error', "Didn't throw.");
+  } catch (e) {
+    t.expect('format syntax error', e.name, 'SyntaxError');
+  }
+};
@@ -864,8 +864,8 @@ exports.testAsync = function(t) {
 };
 
 /**
- * Run tests of the Thread constructor and the suspend(), setTimeout()
- * and clearTimeout() functions.
+ * Run tests of the Thread constructor and the suspend(), setTimeout(),
+ * clearTimeout(), setInterval() and clearInterval() functions.
  * @param {!T} t The test runner object.
  */
 exports.testThreading = function(t) {
@@ -957,6 +957,541 @@ exports.testThreading = function(t) {
       s;
   `;
   runTest(t, 'clearTimeout', src, '1235');
+
+  src = `
+      var s = '';
+      var tid = setInterval(function(x) {s += x;}, 100, 'x');
+      while (s.length < 3) suspend(10);
+      clearInterval(tid);
+      suspend(1000);
+      s;
+  `;
+  runTest(t, 'setInterval', src, 'xxx');
+
+  src = `
+      // Should have no effect:
+      clearInterval('foo');
+
+      var n = 0;
+      var tid = setInterval(function() {
+        if (++n === 3) clearInterval(tid);
+      }, 100);
+      suspend(1000);
+      n;
+  `;
+  runTest(t, 'clearInterval from callback', src, 3);
+};
+
+/**
+ * Run tests of the CC.subscribe(), CC.unsubscribe() and CC.publish()
+ * functions.
+ * @param {!T} t The test runner object.
+ */
+exports.testPubSub = function(t) {
+  let src = `
+      var s = '';
+      var f = function(value, topic) {s += topic + ':' + value + ' ';};
+      CC.subscribe('weather', f);
+      CC.subscribe('weather', f);  // Duplicate subscription ignored.
+      CC.subscribe('time', function(value) {s += 'time ';});
+      var n = CC.publish('weather', 'rain');
+      s += 'published ';  // Delivery is asynchronous.
+      suspend();
+      CC.unsubscribe('weather', f);
+      CC.publish('weather', 'snow');
+      suspend();
+      s + n;
+  `;
+  runTest(t, 'CC.publish', src, 'published weather:rain 1');
+
+  src = `
+      var f = function() {};
+      CC.subscribe('topic', f);
+      [CC.unsubscribe('topic', f), CC.unsubscribe('topic', f),
+       CC.unsubscribe('no such topic', f)].join();
+  `;
+  runTest(t, 'CC.unsubscribe', src, 'true,false,false');
+
+  src = `
+      try {
+        CC.subscribe('topic', 'not a function');
+      } catch (e) {
+        e.name;
+      }
+  `;
+  runTest(t, 'CC.subscribe non-function', src, 'TypeError');
+};
+
+/**
+ * Run tests of maintenance mode.
+ * @param {!T} t The test runner object.
+ */
+exports.testMaintenance = function(t) {
+  const src = `
+      var s = '';
+      var user = {};
+      CC.setMaintenance('Back soon');
+      (function() {
+        setPerms(user);
+        new Thread(function() {s += 'user ';});
+      })();
+      new Thread(function() {s += 'system ';});
+      suspend();
+      s += CC.getMaintenance() + ' ';
+      CC.setMaintenance(null);
+      suspend();
+      s + CC.getMaintenance();
+  `;
+  runTest(t, 'CC.setMaintenance', src, 'system Back soon user null');
+};
+
+/**
+ * Run tests of the slow task log and the CC.slowTasks native.
+ * @param {!T} t The test runner object.
+ */
+exports.testSlowTasks = function(t) {
+  let src = `
+      function spin() {
+        for (var i = 0; i < 1000; i++) {}
+      }
+      var before = CC.slowTasks().length;  // Startup code may be slow.
+      spin();
+      var tasks = CC.slowTasks();
+      var task = tasks[tasks.length - 1];
+      (tasks.length - before) + ' ' + (task.steps >= 1000) + ' ' +
+          /at spin/.test(task.stack);
+  `;
+  runTest(t, 'CC.slowTasks', src, '1 true true',
+      {options: {slowTaskSteps: 1000, noLog: ['slow']}});
+
+  src = `
+      for (var i = 0; i < 1000; i++) {}
+      CC.slowTasks().length;
+  `;
+  runTest(t, 'CC.slowTasks disabled', src, 0);
+};
+
+/**
+ * Run tests of the CC.encode and CC.decode natives.
+ * @param {!T} t The test runner object.
+ */
+exports.testEncode = function(t) {
+  const cases = [
+    ['42', '42'],
+    ['undefined', 'undefined'],
+    ['-0', '-Infinity'],  // 1 / -0.
+    ['NaN', 'NaN'],
+    ['"foo"', 'foo'],
+    ['[1, , 3]', '1,,3'],
+    ['{a: [1, {b: 2}]}', '{"a":[1,{"b":2}]}'],
+  ];
+  for (const tc of cases) {
+    const src = `
+        var v = CC.decode(CC.encode(${tc[0]}));
+        v === -0 && 1 / v < 0 ? String(1 / v) :
+            typeof v === 'object' && !Array.isArray(v) ? JSON.stringify(v) :
+            String(v);
+    `;
+    runTest(t, 'CC.encode(' + tc[0] + ')', src, tc[1]);
+  }
+
+  let src = `
+      var o = {d: new Date(1000), r: /x/gi, e: new TypeError('oops'),
+               f: Math.max, p: Object.create(null)};
+      o.self = o;
+      Object.defineProperty(o, 'hidden', {value: 'h', enumerable: false});
+      Object.preventExtensions(o.p);
+      var c = CC.decode(CC.encode(o));
+      [c !== o, c.self === c, c.d instanceof Date, c.d.getTime(),
+       String(c.r), c.r !== o.r, c.e instanceof TypeError, c.e.message,
+       c.f === Math.max, Object.getPrototypeOf(c.p) === null,
+       Object.isExtensible(c.p), c.hidden,
+       Object.getOwnPropertyDescriptor(c, 'hidden').enumerable].join();
+  `;
+  runTest(t, 'CC.encode/decode preserve structure', src,
+      'true,true,true,1000,/x/gi,true,true,oops,true,true,false,h,false');
+
+  src = `
+      function F() {}
+      var c = CC.decode(CC.encode(new F));
+      Object.getPrototypeOf(c) === Object.prototype;
+  `;
+  runTest(t, 'CC.encode drops non-builtin prototypes', src, true);
+
+  for (const bad of ['function() {}', '{f: function() {}}', 'new WeakMap']) {
+    src = `
+        try {
+          CC.encode(${bad});
+        } catch (e) {
+          e.name;
+        }
+    `;
+    runTest(t, 'CC.encode(' + bad + ') throws', src, 'TypeError');
+  }
+
+  const badEncodings = [
+    '"not JSON"',
+    '"{}"',
+    '\'{"value": {"#": 3}, "objects": []}\'',
+    '\'{"value": 0, "objects": [{"type": "Thread"}]}\'',
+  ];
+  for (const bad of badEncodings) {
+    src = `
+        try {
+          CC.decode(${bad});
+        } catch (e) {
+          e.name;
+        }
+    `;
+    runTest(t, 'CC.decode(' + bad + ') throws', src, 'SyntaxError');
+  }
+};
+
+/**
+ * Run tests of Interpreter.prototype.freezeBuiltins.
+ * @param {!T} t The test runner object.
+ */
+exports.testFreezeBuiltins = function(t) {
+  const freeze = {onCreate: (intrp) => {intrp.freezeBuiltins();}};
+  const cases = [
+    ['Array.prototype.evil = 42;', 'TypeError'],
+    ['Array.prototype.push = null;', 'TypeError'],
+    ['delete Object.prototype.toString;', 'TypeError'],
+    ['Object.defineProperty(Function.prototype, "x", {value: 1});',
+     'TypeError'],
+    ['var o = {}; o.x = 42; o.x;', 42],
+    ['Object.defineProperty({}, "toString", {value: 1}).toString;', 1],
+    // Intrinsics defined by startup files, and polyfilled methods:
+    ['Map.prototype.get = null;', 'TypeError'],
+    ['Promise.resolve = null;', 'TypeError'],
+    ['Math.evil = 42;', 'TypeError'],
+    ['Array.prototype.find.evil = 42;', 'TypeError'],
+    ['setTimeout.evil = 42;', 'TypeError'],
+    ['new Map().set("x", 42).get("x");', 42],
+    ['new Set([1, 2, 2]).size;', 2],
+    ['[1, 2, 3].find(function(x) {return x > 1;});', 2],
+    ['Object.isFrozen(Array.prototype);', true],
+    // Shadowing properties of frozen objects by assignment still works:
+    ['var e = new Error("x"); e.name = "Foo"; String(e);', 'Foo: x'],
+    ['function F() {}\n' +
+         'F.prototype.toString = function() {return "F";};\n' +
+         'String(new F());', 'F'],
+    ['var o = {}; o.toString = function() {return "o";}; String(o);', 'o'],
+  ];
+  for (const tc of cases) {
+    const src = `
+        try {
+          ${tc[0]}
+        } catch (e) {
+          e.name;
+        }
+    `;
+    runTest(t, 'freezeBuiltins: ' + tc[0], src, tc[1], freeze);
+  }
+};
+
+/**
+ * Run tests of Interpreter.prototype.wallTime.
+ * @param {!T} t The test runner object.
+ */
+exports.testWallTime = function(t) {
+  const time = Date.UTC(2000, 0, 1);
+  const clock = {onCreate: (intrp) => {intrp.wallTime = () => time;}};
+  const src = `
+      [Date.now(), new Date().getTime(), new Date(42).getTime(),
+       Date() === new Date().toString()].join();
+  `;
+  runTest(t, 'wallTime', src, time + ',' + time + ',42,true', clock);
+};
+
+/**
+ * Run tests of the Promise polyfill.
+ * @param {!T} t The test runner object.
+ */
+exports.testPromise = function(t) {
+  const wait = `
+      function wait(done) {
+        for (var i = 0; i < 100 && !done(); i++) suspend(1);
+      }
+  `;
+  let src = wait + `
+      var log = [];
+      var p = new Promise(function(resolve) {
+        log.push('executor');
+        resolve(1);
+      });
+      p.then(function(v) {
+        log.push('then ' + v);
+        return v + 1;
+      }).then(function(v) {
+        log.push('then ' + v);
+        throw new Error('oops');
+      }).then(function() {
+        log.push('skipped');
+      }).catch(function(e) {
+        log.push('catch ' + e.message);
+        return Promise.resolve(42);
+      }).then(function(v) {
+        log.push('done ' + v);
+      });
+      log.push('sync');
+      wait(function() {return log.length === 6;});
+      log.join();
+  `;
+  runTest(t, 'Promise chain', src,
+      'executor,sync,then 1,then 2,catch oops,done 42');
+
+  src = wait + `
+      var r;
+      Promise.all([1, Promise.resolve(2), {then: function(f) {f(3);}}])
+          .then(function(values) {r = values.join();});
+      wait(function() {return r;});
+      r;
+  `;
+  runTest(t, 'Promise.all', src, '1,2,3');
+
+  src = wait + `
+      var r;
+      Promise.all([Promise.reject('no'), new Promise(function() {})])
+          .then(null, function(e) {r = 'rejected: ' + e;});
+      wait(function() {return r;});
+      r;
+  `;
+  runTest(t, 'Promise.all rejects', src, 'rejected: no');
+
+  src = wait + `
+      var r;
+      new Promise(function(resolve, reject) {
+        reject(1);
+        resolve(2);
+        throw 3;
+      }).then(null, function(e) {r = e;});
+      wait(function() {return r;});
+      r;
+  `;
+  runTest(t, 'Promise settles once', src, 1);
+};
+
+/**
+ * Run tests of Interpreter.prototype.makeReadOnly.
+ * @param {!T} t The test runner object.
+ */
+exports.testMakeReadOnly = function(t) {
+  const readOnly = {onCreate: (intrp) => {
+    const obj = new intrp.Object(intrp.ROOT);
+    obj.set('x', 42, intrp.ROOT);
+    intrp.global.createMutableBinding('ro', obj);
+    intrp.makeReadOnly([obj], ['w']);
+  }};
+  const cases = [
+    ['ro.x = 0;', 'TypeError'],
+    ['ro.y = 0;', 'TypeError'],
+    ['delete ro.x;', 'TypeError'],
+    ['Object.defineProperty(ro, "y", {value: 0});', 'TypeError'],
+    ['Object.setPrototypeOf(ro, null);', 'TypeError'],
+    ['Object.preventExtensions(ro);', 'TypeError'],
+    ['ro.x;', 42],
+    ['ro.x = 42;', 42],  // Unchanged, so allowed.
+    // Objects inheriting from read-only ones are unaffected:
+    ['var o = Object.create(ro); o.x = 69; o.x;', 69],
+    ['var o = {}; o.x = 69; delete o.x; o.x;', undefined],
+    // Except for writable keys:
+    ['ro.w = 1; ro.w;', 1],
+    ['Object.defineProperty(ro, "w", {value: 2, configurable: true});\n' +
+         'delete ro.w;', true],
+    // Natives with external effects are disabled:
+    ['CC.xhr("http://localhost/");', 'TypeError'],
+    ['CC.connectionListen(9999, {});', 'TypeError'],
+  ];
+  for (const tc of cases) {
+    const src = `
+        try {
+          ${tc[0]}
+        } catch (e) {
+          e.name;
+        }
+    `;
+    runTest(t, 'makeReadOnly: ' + tc[0], src, tc[1], readOnly);
+  }
+};
+
+/**
+ * Run tests of the CC.observe and CC.unobserve natives.
+ * @param {!T} t The test runner object.
+ */
+exports.testObserve = function(t) {
+  const src = `
+      var o = {}, log = [];
+      function observer(obj, key, type) {
+        log.push(key + ':' + type + ':' + (obj === o));
+      }
+      CC.observe(o, observer);
+      o.a = 1;
+      delete o.a;
+      delete o.b;
+      Object.defineProperty(o, 'c', {value: 3});
+      suspend();
+      var r = CC.unobserve(o, observer) + ' ' + CC.unobserve(o, observer);
+      o.d = 4;
+      suspend();
+      log.join() + ' ' + r;
+  `;
+  runTest(t, 'CC.observe', src, 'a:set:true,a:delete:true,c:set:true true false');
+
+  for (const args of ['42, function() {}', '{}, {}']) {
+    const src = `
+        try {
+          CC.observe(${args});
+        } catch (e) {
+          e.name;
+        }
+    `;
+    runTest(t, 'CC.observe(' + args + ') throws', src, 'TypeError');
+  }
+};
+
+/**
+ * Run tests of the CC.deepEqual native.
+ * @param {!T} t The test runner object.
+ */
+exports.testDeepEqual = function(t) {
+  const cases = [
+    ['1, 1', true],
+    ['1, "1"', false],
+    ['NaN, NaN', true],
+    ['0, -0', true],
+    ['{}, {}', true],
+    ['{a: 1, b: [2, 3]}, {b: [2, 3], a: 1}', true],
+    ['{a: 1}, {a: 1, b: undefined}', false],
+    ['{a: 1}, {a: 2}', false],
+    ['[1, 2], {0: 1, 1: 2, length: 2}', false],
+    ['{}, Object.create(null)', false],
+    ['function() {}, function() {}', false],
+    ['Math.max, Math.max', true],
+    ['new Date(0), new Date(0)', true],
+    ['new Date(0), new Date(1)', false],
+    ['/a/g, /a/g', true],
+    ['/a/g, /a/i', false],
+    ['(function() {var o = {}; o.o = o; return o;})(), ' +
+         '(function() {var o = {}; o.o = {o: o}; return o;})()', true],
+  ];
+  for (const tc of cases) {
+    const src = `CC.deepEqual(${tc[0]});`;
+    runTest(t, 'CC.deepEqual(' + tc[0] + ')', src, tc[1]);
+  }
+};
+
+/**
+ * Run tests of the CC.patchFunction native.
+ * @param {!T} t The test runner object.
+ */
+exports.testPatchFunction = function(t) {
+  let src = `
+      var make = function() {var n = 0; return function() {return ++n;};};
+      var counter = make();
+      counter();
+      counter();
+      // The new body runs in the old closure, so n is preserved.
+      var old = CC.patchFunction(counter, function() {return n += 10;});
+      [counter(), old(), counter(), old === counter, counter.length].join();
+  `;
+  runTest(t, 'CC.patchFunction preserves closure', src, '12,13,23,false,0');
+
+  src = `
+      var g = function() {
+        CC.patchFunction(g, function() {return 'new';});
+        return 'old';
+      };
+      // The call in progress finishes running the old body.
+      [g(), g()].join();
+  `;
+  runTest(t, 'CC.patchFunction in-flight call', src, 'old,new');
+
+  src = `
+      var f = function() {};
+      [CC.patchFunction(f, Math.max), CC.patchFunction(Math.max, f),
+       CC.patchFunction(f, f), CC.patchFunction({}, f)].join() +
+          String(f());
+  `;
+  runTest(t, 'CC.patchFunction non-user functions', src, ',,,undefined');
+};
+
+/**
+ * Run tests of the CC.blockedThreads native and the blockedThreadMs
+ * option.
+ * @param {!T} t The test runner object.
+ */
+exports.testBlockedThreads = function(t) {
+  let resolve;
+  function createAsync(intrp) {
+    intrp.global.createMutableBinding('async', new intrp.NativeFunction({
+      name: 'async', length: 0,
+      call: function(intrp, thread, state, thisVal, args) {
+        resolve = intrp.getResolveReject(thread, state).resolve;
+        return Interpreter.FunctionResult.Block;
+      }
+    }));
+  };
+
+  const src = `
+      var result;
+      setTimeout(function() {
+        var b = CC.blockedThreads();
+        result = b.length + ' ' + (b[0].on === async) + ' ' +
+            (b[0].waiting >= 100) + ' ' + /at "/.test(b[0].stack);
+      }, 100);
+      async();
+      result + ' ' + CC.blockedThreads().length;
+  `;
+  runTest(t, 'CC.blockedThreads', src, '1 true true true 0', {
+    options: {blockedThreadMs: 50, noLog: ['blocked']},
+    onCreate: createAsync,
+    onBlocked: (intrp) => {resolve();},
+  });
+};
+
+/**
+ * Run tests of the CC.lint native.
+ * @param {!T} t The test runner object.
+ */
+exports.testLint = function(t) {
+  let src = `
+      var w = CC.lint('function(a) {\\n  var b;\\n  return c;\\n}');
+      w.map(function(w) {
+        return w.line + ':' + w.col + ' ' + w.message;
+      }).join('; ');
+  `;
+  runTest(t, 'CC.lint', src,
+      "2:7 'b' is declared but never used; 3:10 'c' is not declared");
+
+  src = `
+      try {
+        CC.lint('function(');
+      } catch (e) {
+        e.name;
+      }
+  `;
+  runTest(t, 'CC.lint syntax error', src, 'SyntaxError');
+};
+
+/**
+ * Run tests of the CC.format native.
+ * @param {!T} t The test runner object.
+ */
+exports.testFormat = function(t) {
+  let src = `
+      CC.format('if (x) {\\n        y();\\n}', 4);
+  `;
+  runTest(t, 'CC.format', src, 'if (x) {\n    y();\n}');
+
+  src = `
+      try {
+        CC.format('"unterminated');
+      } catch (e) {
+        e.name;
+      }
+  `;
+  runTest(t, 'CC.format syntax error', src, 'SyntaxError');
 };
 
 /**
@@ -1393,6 +1928,31 @@ exports.testNetworking = async function(t) {
     onCreate: createSend
   });
 
+  // Run a test of a non-root-owned server receiving data while in
+  // maintenance mode.
+  name = 'testServerInboundMaintenance';
+  src = `
+      var data = '', conn = {};
+      conn.onReceive = function(d) {
+        data += d;
+      };
+      conn.onEnd = function() {
+        CC.connectionUnlisten(8888);
+        CC.setMaintenance(null);
+        resolve(data);
+      };
+      (function() {
+        setPerms({});
+        CC.connectionListen(8888, conn);
+      })();
+      CC.setMaintenance('Back soon');
+      send();
+   `;
+  await runAsyncTest(t, name, src, 'foobar', {
+    options: {noLog: ['net']},
+    onCreate: createSend
+  });
+
   // Run a test of the connectionListen(), connectionUnlisten(),
   // connectionWrite() and connectionClose functions.
   name = 'testServerOutbound';
@@ -1434,6 +1994,31 @@ exports.testNetworking = async function(t) {
     onCreate: createReceive,
   });
 
+  // Run a test of the .onClose method on a connection object, and
+  // check that the connection object no longer refers to the socket.
+  name = 'testServerClose';
+  src = `
+      var conn = {};
+      conn.onConnect = function() {
+        CC.connectionClose(this);
+      };
+      conn.onClose = function() {
+        CC.connectionUnlisten(8888);
+        try {
+          CC.connectionWrite(this, 'foo');
+          resolve('Unexpected success writing to closed connection');
+        } catch (e) {
+          resolve(e.name);
+        }
+      };
+      CC.connectionListen(8888, conn);
+      receive();
+   `;
+  await runAsyncTest(t, name, src, 'TypeError', {
+    options: {noLog: ['net']},
+    onCreate: createReceive,
+  });
+
   // Check to make sure that connectionListen() throws if attempting
   // to bind to an invalid port or rebind a port already in use.
   name = 'testConnectionListenThrows';
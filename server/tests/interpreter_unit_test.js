@@ -259,3 +259,118 @@ exports.testSource = function(t) {
   t.expect(name + '.lineColForPos(8).col', lc.line, 1);
   t.expect(name + '.toString()', String(src), '');
 };
+
+/**
+ * Unit tests for Interpreter.Thread.prototype.graph and
+ * Interpreter.Thread.graphToDot.
+ * @param {!T} t The test runner object.
+ */
+exports.testThreadGraph = function(t) {
+  const intrp = new Interpreter;
+  const src = `
+      var suspend = new 'Thread.suspend';
+      (function f(a) {
+        var o = {x: 'hi'};
+        suspend(10000);
+      })(42);
+  `;
+  const thread = intrp.createThreadForSrc(src).thread;
+  intrp.run();
+  const graph = thread.graph(intrp.ROOT);
+
+  t.expect('graph.states[0].type', graph.states[0].type, 'Program');
+  const scope = graph.scopes.find((s) => s.type === 'function');
+  t.assert('function scope found', scope !== undefined);
+  t.expect('scope.vars.a', scope.vars.a, '42');
+  const outer = graph.scopes.find((s) => s.id === scope.outer);
+  // Named function expression f has its own scope binding 'f'.
+  t.expect('outer scope type', outer && outer.type, 'funexp');
+  const ref = scope.vars.o && scope.vars.o.ref;
+  const obj = graph.objects.find((o) => o.id === ref);
+  t.expect('o.class', obj && obj.class, 'Object');
+  t.expect('o.properties.x', obj && obj.properties.x, '"hi"');
+
+  const dot = Interpreter.Thread.graphToDot(graph);
+  t.assert('dot starts with digraph', dot.startsWith('digraph thread {\n'));
+  t.assert('dot has o edge',
+      dot.includes('  ' + scope.id + ' -> ' + ref + ' [label="o"];'));
+  t.assert('dot has outer edge',
+      dot.includes('  ' + scope.id + ' -> ' + outer.id + ' [label="outer"];'));
+};
+
+/**
+ * Unit tests for the filename argument to
+ * Interpreter.prototype.createThreadForSrc.
+ * @param {!T} t The test runner object.
+ */
+exports.testCreateThreadForSrcFilename = function(t) {
+  const intrp = new Interpreter;
+  const src = `
+      var suspend = new 'Thread.suspend';
+      suspend(10000);
+  `;
+  const named = intrp.createThreadForSrc(src, undefined, 'core_99_test.js');
+  const anon = intrp.createThreadForSrc(src);
+  intrp.run();
+
+  let callers = named.thread.callers(intrp.ROOT);
+  const frame = callers[callers.length - 1];
+  t.expect('named frame.file', frame.file, 'core_99_test.js');
+  t.expect('named frame.program', frame.program, src);
+  t.assert('named stack mentions file',
+      intrp.formatCallers(callers, intrp.ROOT).includes(
+          'at core_99_test.js 3:7'));
+
+  callers = anon.thread.callers(intrp.ROOT);
+  t.assert('anon frame has no file',
+      !('file' in callers[callers.length - 1]));
+};
+
+/**
+ * Unit tests for Interpreter.prototype.killThread.
+ * @param {!T} t The test runner object.
+ */
+exports.testKillThread = function(t) {
+  const intrp = new Interpreter;
+  const src = `
+      var suspend = new 'Thread.suspend';
+      var x = 'before';
+      suspend(10000);
+      x = 'after';
+  `;
+  const thread = intrp.createThreadForSrc(src).thread;
+  const sleeping = intrp.run();
+  t.assert('run() leaves thread sleeping', sleeping > 0);
+
+  t.expect('killThread(id)', intrp.killThread(thread.id), true);
+  t.expect('killThread(id) again', intrp.killThread(thread.id), false);
+  t.expect('killThread(unknown id)', intrp.killThread(9999), false);
+  t.expect('run() after kill', intrp.run(), 0);
+  t.expect('x', intrp.pseudoToNative(intrp.global.get('x')), 'before');
+};
+
+/**
+ * Unit tests for Interpreter.prototype.observe and .unobserve.
+ * @param {!T} t The test runner object.
+ */
+exports.testObserve = function(t) {
+  const intrp = new Interpreter;
+  const obj = new intrp.Object(intrp.ROOT);
+  const other = new intrp.Object(intrp.ROOT);
+  const log = [];
+  const callback = (o, key, type) => {
+    log.push((o === obj ? 'obj' : '?') + '.' + key + ':' + type);
+  };
+  intrp.observe(obj, callback);
+  obj.set('x', 1, intrp.ROOT);
+  other.set('x', 1, intrp.ROOT);
+  obj.set('y', 2, intrp.ROOT);
+  obj.deleteProperty('x', intrp.ROOT);
+  obj.deleteProperty('z', intrp.ROOT);  // Nonexistent; not reported.
+  t.expect('observed changes', log.join(), 'obj.x:set,obj.y:set,obj.x:delete');
+
+  t.expect('unobserve', intrp.unobserve(obj, callback), true);
+  t.expect('unobserve again', intrp.unobserve(obj, callback), false);
+  obj.set('x', 3, intrp.ROOT);
+  t.expect('no changes after unobserve', log.length, 3);
+};
@@ -0,0 +1,85 @@
+/**
+ * @license
+ * Copyright 2020 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/**
+ * @fileoverview Tests for the linter.
+ * @author cpcallen@google.com (Christopher Allen)
+ */
+'use strict';
+
+const {lint} = require('../lint');
+const {T} = require('./testing');
+
+/**
+ * Run lint on src and return warnings formatted as strings.
+ * @param {string} src Source to lint.
+ * @param {function(string): boolean=} isGlobal Global name predicate.
+ * @return {string} Warnings as 'line:col message', joined with '; '.
+ */
+function lintToString(src, isGlobal) {
+  return lint(src, isGlobal).map(
+      (w) => w.line + ':' + w.col + ' ' + w.message).join('; ');
+}
+
+/**
+ * Unit tests for lint.
+ * @param {!T} t The test runner object.
+ */
+exports.testLint = function(t) {
+  const isGlobal = (name) => ['$', 'Object'].includes(name);
+  const cases = [
+    // Clean code.
+    ['function(a) {\n  return a + $.foo;\n}', ''],
+    ['var x = 1; x;', ''],
+    // Unused variables and functions (but not parameters).
+    ['function(a, b) {\n  var c = 1;\n  function d() {}\n  return a;\n}',
+     "2:7 'c' is declared but never used; " +
+     "3:12 'd' is declared but never used"],
+    ['function() {\n  var c;\n  c = 1;\n}',
+     "2:7 'c' is declared but never used"],
+    ['function() {\n  var c = 0;\n  c += 1;\n}', ''],
+    ['function() {\n  try {} catch (e) {}\n}', ''],
+    // Undeclared globals.
+    ['function() {\n  return undeclared + Object;\n}',
+     "2:10 'undeclared' is not declared"],
+    ['function() {\n  return {undeclared: 1}.undeclared;\n}', ''],
+    ['function f() {\n  return f;\n}', ''],
+    ['function() {\n  return arguments;\n}', ''],
+    // Assignment in condition.
+    ['function(a, b) {\n  if (a = b) {}\n}',
+     "2:7 Assignment in condition; did you mean '==='?"],
+    ['function(a, b) {\n  while (a === b) {}\n}', ''],
+    // Missing break.
+    ['function(a) {\n  switch (a) {\n    case 1:\n      a++;\n' +
+     '    case 2:\n    case 3:\n      return a;\n    default:\n  }\n}',
+     '5:5 Fall-through from previous case'],
+  ];
+  for (const [src, expected] of cases) {
+    t.expect('lint(' + JSON.stringify(src) + ')',
+        lintToString(src, isGlobal), expected);
+  }
+
+  // Undeclared names are not reported without isGlobal.
+  t.expect('lint without isGlobal', lintToString('foo;'), '');
+
+  try {
+    lint('function( {');
+    t.fail('lint syntax error', "Didn't throw.");
+  } catch (e) {
+    t.expect('lint syntax error', e.name, 'SyntaxError');
+  }
+};
@@ -545,3 +545,210 @@ exports.testRoundtripAsync = async function(t) {
   });
   server.close();
 };
+
+/**
+ * Run tests of migrating serialized interpreters between versions.
+ * @param {!T} t The test runner object.
+ */
+exports.testMigrate = function(t) {
+  const name = 'testMigrate';
+  const intrp = getInterpreter();
+  intrp.createThreadForSrc('var x = 42;');
+  intrp.run();
+  intrp.pause();
+  const json = Serializer.serialize(intrp);
+  const version = Interpreter.SERIALIZATION_VERSION;
+  t.expect(name + ': getVersion', Serializer.getVersion(json), version);
+  t.expect(name + ': current version', Serializer.migrate(json), 0);
+
+  // Pretend json is two versions old, and supply migrations to upgrade it.
+  const oldMigrations = Serializer.migrations;
+  const log = [];
+  try {
+    json[0]['props']['serializationVersion'] = version - 2;
+    Serializer.migrations = {
+      [version - 2]: function(json) { log.push('A'); },
+      [version - 1]: function(json) { log.push('B'); },
+    };
+    t.expect(name + ': migrations applied', Serializer.migrate(json), 2);
+    t.expect(name + ': migration order', log.join(), 'A,B');
+    t.expect(name + ': version updated', Serializer.getVersion(json), version);
+
+    // Missing migration.
+    json[0]['props']['serializationVersion'] = version - 3;
+    try {
+      Serializer.migrate(json);
+      t.fail(name + ': missing migration', "Didn't throw");
+    } catch (e) {
+      t.pass(name + ': missing migration');
+    }
+  } finally {
+    Serializer.migrations = oldMigrations;
+  }
+
+  // Version from the future.
+  json[0]['props']['serializationVersion'] = version + 1;
+  try {
+    Serializer.migrate(json);
+    t.fail(name + ': newer version', "Didn't throw");
+  } catch (e) {
+    t.pass(name + ': newer version');
+  }
+
+  // Check migrated flatpack can be deserialized.
+  json[0]['props']['serializationVersion'] = version;
+  const intrp2 = new Interpreter;
+  Serializer.deserialize(json, intrp2);
+  intrp2.pause();
+  const thread = intrp2.createThreadForSrc('x;').thread;
+  intrp2.run();
+  t.expect(name + ': deserialized', thread.value, 42);
+};
+
+/**
+ * Run a test of a single migration: a flatpack of the current version
+ * is made to look like one of the given version by removing the
+ * properties which Serializer.migrations[version] should add, that
+ * migration is applied, and check is called to verify that it has
+ * added them.  The flatpack is then migrated (if necessary) the rest
+ * of the way to the current version, deserialized and run.
+ * @param {!T} t The test runner object.
+ * @param {number} version The version to migrate from.
+ * @param {function(!Array<!Object>)} strip Function to remove the
+ *     properties added in version + 1 from the flatpack.
+ * @param {function(string, !Array<!Object>)} check Function to check
+ *     the migrated flatpack; passed the test name and the flatpack.
+ */
+function runMigrationTest(t, version, strip, check) {
+  const name = 'testMigrateFrom' + version;
+  const intrp = getInterpreter();
+  // Leave thread unrun, so that there is a Thread record to migrate.
+  intrp.createThreadForSrc('var x = 42;');
+  intrp.pause();
+  const json = Serializer.serialize(intrp);
+  strip(json);
+  json[0]['props']['serializationVersion'] = version;
+
+  Serializer.migrations[version](json);
+  check(name, json);
+
+  json[0]['props']['serializationVersion'] = version + 1;
+  t.expect(name + ': further migrations applied', Serializer.migrate(json),
+      Interpreter.SERIALIZATION_VERSION - version - 1);
+  const intrp2 = new Interpreter;
+  try {
+    Serializer.deserialize(json, intrp2);
+    intrp2.pause();
+    intrp2.run();
+    const thread = intrp2.createThreadForSrc('x;').thread;
+    intrp2.run();
+    t.expect(name + ': deserialized', thread.value, 42);
+  } catch (e) {
+    t.crash(name + ': deserialized', e);
+  }
+}
+
+/**
+ * Get the records of the given type from a flatpack.
+ * @param {!Array<!Object>} json The flatpack.
+ * @param {string} type The type of record wanted (e.g., 'Thread').
+ * @return {!Array<!Object>} The matching records.
+ */
+function recordsOfType(json, type) {
+  return json.filter((record) => record['type'] === type);
+}
+
+/**
+ * Run a test of migrating a version 1 flatpack to version 2.
+ * @param {!T} t The test runner object.
+ */
+exports.testMigrateFrom1 = function(t) {
+  runMigrationTest(t, 1, function(json) {
+    delete json[0]['props']['subscriptions_'];
+  }, function(name, json) {
+    const ref = json[0]['props']['subscriptions_'];
+    t.expect(name + ': .subscriptions_', ref && json[ref['#']]['type'], 'Map');
+  });
+};
+
+/**
+ * Run a test of migrating a version 2 flatpack to version 3.
+ * @param {!T} t The test runner object.
+ */
+exports.testMigrateFrom2 = function(t) {
+  runMigrationTest(t, 2, function(json) {
+    delete json[0]['props']['maintenanceMessage'];
+  }, function(name, json) {
+    t.expect(name + ': .maintenanceMessage',
+        json[0]['props']['maintenanceMessage'], null);
+  });
+};
+
+/**
+ * Run a test of migrating a version 3 flatpack to version 4.
+ * @param {!T} t The test runner object.
+ */
+exports.testMigrateFrom3 = function(t) {
+  runMigrationTest(t, 3, function(json) {
+    for (const record of recordsOfType(json, 'Thread')) {
+      delete record['props']['blockedSince'];
+      delete record['props']['blockedOn'];
+      delete record['props']['blockedReported'];
+    }
+  }, function(name, json) {
+    const threads = recordsOfType(json, 'Thread');
+    t.assert(name + ': has Thread records', threads.length > 0);
+    for (const record of threads) {
+      const props = record['props'];
+      t.expect(name + ': .blockedSince', props['blockedSince'], 0);
+      t.expect(name + ': .blockedOn', props['blockedOn'], null);
+      t.expect(name + ': .blockedReported', props['blockedReported'], false);
+    }
+  });
+};
+
+/**
+ * Run a test of migrating a version 4 flatpack to version 5.
+ * @param {!T} t The test runner object.
+ */
+exports.testMigrateFrom4 = function(t) {
+  runMigrationTest(t, 4, function(json) {
+    delete json[0]['props']['observers_'];
+  }, function(name, json) {
+    const ref = json[0]['props']['observers_'];
+    t.expect(name + ': .observers_', ref && json[ref['#']]['type'],
+        'IterableWeakMap');
+  });
+};
+
+/**
+ * Run a test of migrating a version 5 flatpack to version 6.
+ * @param {!T} t The test runner object.
+ */
+exports.testMigrateFrom5 = function(t) {
+  runMigrationTest(t, 5, function(json) {
+    for (const record of recordsOfType(json, 'Thread')) {
+      delete record['props']['network'];
+    }
+  }, function(name, json) {
+    const threads = recordsOfType(json, 'Thread');
+    t.assert(name + ': has Thread records', threads.length > 0);
+    for (const record of threads) {
+      t.expect(name + ': .network', record['props']['network'], false);
+    }
+  });
+};
+
+/**
+ * Run a test of migrating a version 6 flatpack to version 7.
+ * @param {!T} t The test runner object.
+ */
+exports.testMigrateFrom6 = function(t) {
+  runMigrationTest(t, 6, function(json) {
+    delete json[0]['props']['frozen_'];
+  }, function(name, json) {
+    const ref = json[0]['props']['frozen_'];
+    t.expect(name + ': .frozen_', ref && json[ref['#']]['type'],
+        'IterableWeakSet');
+  });
+};
@@ -728,6 +728,142 @@ module.exports = [
     `,
     expected: 'TypeError' },
 
+  { name: 'binaryCallsValueOf', src: `
+    var o = {valueOf: function() {return 42;}};
+    [o + 1, o - 2, o * 2, o > 41, o == 42, o == '42', o === 42,
+     o | 1].join();
+    `,
+    expected: '43,40,84,true,true,true,false,43' },
+
+  { name: 'binaryCallsToString', src: `
+    var o = {toString: function() {return 'foo';}};
+    o + 'bar';
+    `,
+    expected: 'foobar' },
+
+  { name: 'binaryCallsToStringIfValueOfReturnsObject', src: `
+    var o = Object.create(null);
+    o.valueOf = function() {return {};};
+    o.toString = function() {return 'foo';};
+    o + 'bar';
+    `,
+    expected: 'foobar' },
+
+  { name: 'binaryDateCallsToString', src: `
+    var d = new Date(0);
+    (d + '' === d.toString()) && (d - 0 === 0);
+    `,
+    expected: true },
+
+  { name: 'binaryObjectsCompareByIdentity', src: `
+    var calls = 0;
+    var o = {valueOf: function() {calls++; return 1;}};
+    (o == o) + ',' + (o == {}) + ',' + (o == null) + ',' + calls;
+    `,
+    expected: 'true,false,false,0' },
+
+  { name: 'binaryToPrimitiveThrows', src: `
+    var o = Object.create(null);
+    try {
+      o + 1;
+    } catch (e) {
+      e.name;
+    }
+    `,
+    expected: 'TypeError' },
+
+  { name: 'compoundAssignmentCallsValueOf', src: `
+    var o = {valueOf: function() {return 2;}};
+    var x = 40;
+    x += o;
+    var p = o;
+    p *= 3;
+    x + ',' + p;
+    `,
+    expected: '42,6' },
+
+  { name: 'unaryCallsValueOf', src: `
+    var o = {valueOf: function() {return '42';}};
+    [+o, -o, ~o, +new Date(7), !o].join();
+    `,
+    expected: '42,-42,-43,7,false' },
+
+  { name: 'unaryToPrimitiveThrows', src: `
+    var o = Object.create(null);
+    try {
+      -o;
+    } catch (e) {
+      e.name;
+    }
+    `,
+    expected: 'TypeError' },
+
+  { name: 'updateCallsValueOf', src: `
+    var calls = 0;
+    var o = {valueOf: function() {calls++; return 41;}};
+    var x = o;
+    var y = x++;
+    var obj = {p: o};
+    var z = --obj.p;
+    [x, y, obj.p, z, calls].join();
+    `,
+    expected: '42,41,40,40,2' },
+
+  { name: 'memberExpressionKeyCallsToString', src: `
+    var calls = [];
+    var k = {
+      toString: function() {calls.push('toString'); return 'k';},
+      valueOf: function() {calls.push('valueOf'); return 'v';}
+    };
+    var m = {k: 42};
+    var r = [m[k], k in m];
+    m[k] = 43;
+    m[k]++;
+    delete m[k];
+    r.push(m.k, calls.length, calls.indexOf('valueOf'));
+    r.join();
+    `,
+    expected: '42,true,,5,-1' },
+
+  { name: 'memberExpressionKeyToPrimitiveThrows', src: `
+    var m = {};
+    try {
+      m[Object.create(null)];
+    } catch (e) {
+      e.name;
+    }
+    `,
+    expected: 'TypeError' },
+
+  { name: 'nativeArgumentsCallValueOf', src: `
+    var o = {valueOf: function() {return 3;}};
+    var s = {toString: function() {return '1';}};
+    [isNaN(o), isNaN({valueOf: function() {return NaN;}}), isFinite(o),
+     Math.max(o, 1), Math.pow(o, o), parseInt(s), parseFloat(s),
+     String.fromCharCode({valueOf: function() {return 65;}}),
+     'abcd'.charAt(o), 'abcd'.indexOf({toString: function() {return 'c';}}),
+     [1, 2, 3, 4].slice(o).length, [1, 2, 3, 4].indexOf(4, o),
+     (7).toFixed(s), new Date(0).setTime(o)].join();
+    `,
+    expected: 'false,true,true,3,27,1,1,A,d,2,1,3,7.0,3' },
+
+  { name: 'nativeArgumentsToPrimitiveThrows', src: `
+    try {
+      Math.max(1, Object.create(null));
+    } catch (e) {
+      e.name;
+    }
+    `,
+    expected: 'TypeError' },
+
+  { name: 'nativeArgumentsConvertedViaApply', src: `
+    var o = {valueOf: function() {return 5;}};
+    var max = Math.max.bind(undefined, o);
+    [Math.min.apply(undefined, [o, 7]), Math.abs.call(undefined, o),
+     max(1)].join();
+    `,
+    expected: '5,5,5' },
+
   { name: 'instanceofBasics', src: `
     function F(){}
     var f = new F;
@@ -979,6 +1115,24 @@ module.exports = [
     expected: '[object Arguments]'
   },
 
+  // Arguments objects always have strict-mode semantics: they are not
+  // aliased to the named parameters, and have no .callee.
+  { name: 'argumentsNotAliased', src: `
+    (function(a, b) {
+      arguments[0] = 'changed';
+      b = 'changed';
+      return a + ' ' + arguments[1];
+    })('a', 'b');
+    `,
+    expected: 'a b' },
+
+  { name: 'argumentsNoCallee', src: `
+    (function() {
+      return 'callee' in arguments;
+    })();
+    `,
+    expected: false },
+
   { name: 'debugger', src: `
     debugger;
     `,
@@ -1244,6 +1398,39 @@ module.exports = [
     `,
     expected: 'TypeError' },
 
+  { name: 'Object.assign', src: `
+    var target = {a: 1};
+    var r = Object.assign(target, {b: 2}, null, {a: 3, c: 4});
+    r === target && JSON.stringify(r);
+    `,
+    expected: '{"a":3,"b":2,"c":4}' },
+
+  { name: 'Object.assign skips non-enumerable properties', src: `
+    var source = Object.create({inherited: true});
+    source.x = 1;
+    Object.defineProperty(source, 'hidden', {value: 2, enumerable: false});
+    var r = Object.assign({}, source);
+    JSON.stringify(r) + ' ' + r.hasOwnProperty('hidden');
+    `,
+    expected: '{"x":1} false' },
+
+  { name: 'Object.entries and Object.values', src: `
+    var o = Object.create({inherited: true});
+    o.x = 1;
+    o.y = 'two';
+    JSON.stringify(Object.entries(o)) + ' ' + JSON.stringify(Object.values(o));
+    `,
+    expected: '[["x",1],["y","two"]] [1,"two"]' },
+
+  { name: 'Object.entries and Object.values skip non-enumerable properties',
+    src: `
+    var o = {x: 1};
+    Object.defineProperty(o, 'hidden', {value: 2, enumerable: false});
+    o.y = 3;
+    JSON.stringify(Object.entries(o)) + ' ' + JSON.stringify(Object.values(o));
+    `,
+    expected: '[["x",1],["y",3]] [1,3]' },
+
   { name: 'Object.create()', src: `
     try {
       Object.create();
@@ -1370,6 +1557,16 @@ module.exports = [
     `,
     expected: 80 },
 
+  { name: 'Object.keys', src: `
+    var o = Object.create({inherited: true});
+    o.foo = 1;
+    Object.defineProperty(o, 'hidden', {value: 2, enumerable: false});
+    o.bar = 3;
+    Object.keys(o).join() + ';' + Object.keys('ab').join() + ';' +
+        Object.keys([4, 5]).join();
+    `,
+    expected: 'foo,bar;0,1;0,1' },
+
   { name: 'Object.defineProperties()', src: `
     try {
       Object.defineProperties();
@@ -1439,6 +1636,42 @@ module.exports = [
     `,
     expected: 82 },
 
+  { name: 'Object.freeze and Object.isFrozen', src: `
+    var o = {foo: 'bar'};
+    var r = [Object.isFrozen(o), Object.freeze(o) === o, Object.isFrozen(o),
+        Object.isSealed(o), Object.isExtensible(o)];
+    try {
+      o.foo = 'baz';
+    } catch (e) {
+      r.push(e.name);
+    }
+    r.join();
+    `,
+    expected: 'false,true,true,true,false,TypeError' },
+
+  { name: 'Object.seal and Object.isSealed', src: `
+    var o = {foo: 'bar'};
+    var r = [Object.isSealed(o), Object.seal(o) === o, Object.isSealed(o),
+        Object.isFrozen(o)];
+    o.foo = 'baz';
+    try {
+      delete o.foo;
+    } catch (e) {
+      r.push(e.name);
+    }
+    r.push(o.foo);
+    r.join();
+    `,
+    expected: 'false,true,true,false,TypeError,baz' },
+
+  { name: 'Object.isFrozen(non-extensible empty object)', src: `
+    var o = {};
+    var r = Object.isFrozen(o);
+    Object.preventExtensions(o);
+    [r, Object.isFrozen(o), Object.isSealed(o)].join();
+    `,
+    expected: 'false,true,true' },
+
   { name: 'Object.prototype.toString', src: `
     ({}).toString();
     `,
@@ -2149,6 +2382,51 @@ module.exports = [
     `,
     expected: '0,1,5,9,10,11,99' },
 
+  { name: 'Array.prototype.sort(comparefn) is stable', src: `
+    var a = [];
+    for (var i = 0; i < 100; i++) a.push({key: i % 3, seq: i});
+    a.sort(function(x, y) {return x.key - y.key;});
+    var ok = true;
+    for (var i = 1; i < a.length; i++) {
+      if (a[i - 1].key === a[i].key && a[i - 1].seq > a[i].seq) ok = false;
+    }
+    ok;
+    `,
+    expected: true },
+
+  { name: 'Array.prototype.sort(comparefn) non-number results', src: `
+    [3, 1, 2].sort(function(a, b) {return String(a - b);}).join() + ' ' +
+        [3, 1, 2].sort(function(a, b) {return NaN;}).join();
+    `,
+    expected: '1,2,3 3,1,2' },
+
+  { name: 'Array.prototype.sort(comparefn) throws', src: `
+    var a = [5, 4, 3, 2, 1, 0, 6, 7, 8, 9, 10, 11, 12];
+    var n = 0;
+    try {
+      a.sort(function(x, y) {
+        if (++n === 20) throw 'oops';
+        return x - y;
+      });
+    } catch (e) {
+      var r = e;
+    }
+    r + ' ' + a.slice().sort(function(x, y) {return x - y;}).join();
+    `,
+    expected: 'oops 0,1,2,3,4,5,6,7,8,9,10,11,12' },
+
+  { name: 'Array.prototype.sort(comparefn) throws, leaving array unmodified',
+    src: `
+    var a = [3, undefined, 2, , 1];
+    try {
+      a.sort(function(x, y) {throw 'oops';});
+    } catch (e) {
+      var r = e;
+    }
+    r + ' ' + a.length + ' ' + (3 in a) + ' ' + String(a);
+    `,
+    expected: 'oops 5 false 3,,2,,1' },
+
   { name: 'Array.prototype.sort(comparefn) compaction', src: `
     ['z', undefined, 10, , 'aa', null, 'a', 5, NaN, , 1].sort(
         function(a, b) {
@@ -2329,6 +2607,33 @@ module.exports = [
     `,
     expected: 'TypeError' },
 
+  /////////////////////////////////////////////////////////////////////////////
+  // Date and Date.prototype
+
+  { name: 'Date.parse ISO 8601',
+    src: `Date.parse('2020-01-02T03:04:05.678Z');`,
+    expected: 1577934245678 },
+
+  { name: 'Date.parse RFC 2822',
+    src: `Date.parse('Thu, 02 Jan 2020 03:04:05 GMT');`,
+    expected: 1577934245000 },
+
+  { name: 'Date.parse invalid', src: `
+    isNaN(Date.parse('not a date'));
+    `,
+    expected: true },
+
+  { name: 'Date.prototype.getUTC*', src: `
+    var d = new Date(Date.UTC(2020, 0, 2, 3, 4, 5, 678));
+    [d.getUTCFullYear(), d.getUTCMonth(), d.getUTCDate(), d.getUTCHours(),
+     d.getUTCMinutes(), d.getUTCSeconds(), d.getUTCMilliseconds()].join();
+    `,
+    expected: '2020,0,2,3,4,5,678' },
+
+  { name: 'Date.prototype.toISOString',
+    src: `new Date(1577934245678).toISOString();`,
+    expected: '2020-01-02T03:04:05.678Z' },
+
   /////////////////////////////////////////////////////////////////////////////
   // Number and Number.prototype
 
@@ -2358,6 +2663,44 @@ module.exports = [
     `,
     expected: 'pass' },
 
+  { name: 'Number calls valueOf', src: `
+        var o = Object.create(null);
+        o.valueOf = function() {return '42';};
+        o.toString = function() {return 'Whoops: called toString';};
+        Number(o);
+    `,
+    expected: 42 },
+
+  { name: 'Number calls toString', src: `
+        var o = Object.create(null);
+        o.valueOf = function() {return {};};
+        o.toString = function() {return '42';};
+        Number(o);
+    `,
+    expected: 42 },
+
+  { name: 'new Number calls valueOf', src: `
+        var calls = 0;
+        var o = {valueOf: function() {calls++; return 42;}};
+        try {
+          new Number(o);
+        } catch (e) {
+          var r = e.name;
+        }
+        r + ',' + calls;
+    `,
+    expected: 'TypeError,1' },
+
+  { name: 'Number calling toString throws', src: `
+        var o = Object.create(null);
+        try {
+          Number(o);
+        } catch (e) {
+          e.name;
+        }
+    `,
+    expected: 'TypeError' },
+
   { name: 'Number.MAX_SAFE_INTEGER', src: `
     Number.MAX_SAFE_INTEGER + 1 === Math.pow(2, 53) &&
         Number.isSafeInteger(Number.MAX_SAFE_INTEGER) &&
@@ -2484,10 +2827,35 @@ module.exports = [
     `,
     expected: 'TypeError' },
 
+  { name: 'String.fromCharCode surrogate pair',
+    src: `String.fromCharCode(0xd83d, 0xde00) === '\\ud83d\\ude00';`,
+    expected: true },
+
+  { name: 'String UTF-16 code units', src: `
+    var s = 'a\\ud83d\\ude00b';  // U+1F600 takes two code units.
+    [s.length, s.charCodeAt(1).toString(16), s.charCodeAt(2).toString(16),
+     s[3], s.indexOf('b'), s.slice(1, 3) === '\\ud83d\\ude00'].join();
+    `,
+    expected: '4,d83d,de00,b,3,true' },
+
   { name: 'String.prototype.length',
     src: `String.prototype.length;`,
     expected: 0 },
 
+  { name: 'String.prototype.localeCompare', src: `
+    ['z', '\u00e9', 'a', 'E'].sort(function(a, b) {
+      return a.localeCompare(b);
+    }).join();
+    `,
+    expected: 'a,E,\u00e9,z' },
+
+  { name: 'String.prototype.match(global regexp)', src: `
+    var re = /a./g;
+    re.lastIndex = 2;
+    'a1a2a3'.match(re).join() + ' ' + re.lastIndex;
+    `,
+    expected: 'a1,a2,a3 0' },
+
   { name: 'String.prototype.replace(string, string)',
     src: `'xxxx'.replace('xx', 'y');`,
     expected: 'yxx' },
@@ -2510,6 +2878,29 @@ module.exports = [
     `,
     expected: '[xx,x,0,xxxx][xx,x,2,xxxx]' },
 
+  { name: 'String.prototype.replace(regexp, function) captures', src: `
+    var re = /([a-z])(\\d)/g;
+    re.lastIndex = 3;
+    'a1b2'.replace(re, function(match, letter, digit) {
+      return digit + letter;
+    }) + ' ' + re.lastIndex;
+    `,
+    expected: '1a2b 0' },
+
+  { name: 'String.prototype.replace(regexp, function) empty matches', src: `
+    'abc'.replace(/x*/g, function(match, index) {
+      return '[' + index + ']';
+    });
+    `,
+    expected: '[0]a[1]b[2]c[3]' },
+
+  { name: 'String.prototype.replace(regexp, string) resets lastIndex', src: `
+    var re = /x/g;
+    re.lastIndex = 1;
+    'xx'.replace(re, 'y') + ' ' + re.lastIndex;
+    `,
+    expected: 'yy 0' },
+
   { name: 'String.prototype.search(string) not found',
     src: `'hello'.search('H')`,
     expected: -1 },
@@ -2526,6 +2917,32 @@ module.exports = [
     src: `'hello'.search(/(.)\\1/)`,
     expected: 2 },
 
+  { name: 'String.prototype.split(regexp)',
+    src: `'a, b,c'.split(/,\\s*/).join('|');`,
+    expected: 'a|b|c' },
+
+  { name: 'String.prototype.split(regexp) captures', src: `
+    var r = 'ab'.split(/(x)?b/);
+    r.length + ' ' + r[0] + ' ' + typeof r[1] + ' "' + r[2] + '"';
+    `,
+    expected: '3 a undefined ""' },
+
+  { name: 'String.prototype.split(regexp, limit)',
+    src: `'a1b2c3'.split(/(\\d)/, 3).join('|');`,
+    expected: 'a|1|b' },
+
+  { name: 'String.prototype.split(empty regexp)',
+    src: `'abc'.split(/(?:)/).join('|');`,
+    expected: 'a|b|c' },
+
+  { name: 'String.prototype.toLowerCase Unicode',
+    src: `'\u0391\u0392\u0393 \u00c9T\u00c9'.toLowerCase();`,
+    expected: '\u03b1\u03b2\u03b3 \u00e9t\u00e9' },
+
+  { name: 'String.prototype.toUpperCase Unicode',
+    src: `'stra\u00dfe \u00e9t\u00e9'.toUpperCase();`,
+    expected: 'STRASSE \u00c9T\u00c9' },
+
   { name: 'String.prototype.toString()', src: `
     String.prototype.toString();
     `,
@@ -2576,6 +2993,16 @@ module.exports = [
     `,
     expected: 'TypeError' },
 
+  { name: 'RegExp.prototype.test global lastIndex', src: `
+    var re = /a/g;
+    var r = [];
+    for (var i = 0; i < 3; i++) {
+      r.push(re.test('aa'), re.lastIndex);
+    }
+    r.join();
+    `,
+    expected: 'true,1,true,2,false,0' },
+
   /////////////////////////////////////////////////////////////////////////////
   // Error and Error.prototype (and all the other native error types too)
 
@@ -2635,6 +3062,46 @@ module.exports = [
     `,
     expected: 'at foo 2:14' },
 
+  { name: 'Error subclass', src: `
+    function MyError(message) {
+      this.message = message;
+    }
+    MyError.prototype = Object.create(Error.prototype);
+    MyError.prototype.constructor = MyError;
+    MyError.prototype.name = 'MyError';
+    var e = new MyError('oops');
+    [e instanceof MyError, e instanceof Error, String(e)].join();
+    `,
+    expected: 'true,true,MyError: oops' },
+
+  { name: 'Error.captureStackTrace', src: `
+    function MyError(message) {
+      this.message = message;
+      Error.captureStackTrace(this, MyError);
+    }
+    MyError.prototype = Object.create(Error.prototype);
+    function foo() {
+      throw new MyError('oops');
+    }
+    try {
+      foo();
+    } catch (e) {
+      var lines = e.stack.split('\\n');
+    }
+    lines[0].trim();
+    `,
+    expected: 'at foo 2:13' },
+
+  { name: 'Error.captureStackTrace without constructorOpt', src: `
+    function foo() {
+      var o = {};
+      Error.captureStackTrace(o);
+      return o.stack.split('\\n')[0].trim();
+    }
+    foo();
+    `,
+    expected: 'at foo 3:7' },
+
   /////////////////////////////////////////////////////////////////////////////
   // JSON
 
@@ -2745,6 +3212,94 @@ module.exports = [
     `,
     expected: 0 },
 
+  { name: 'WeakSet', src: `
+    var w = new WeakSet;
+    var o = {};
+    var fails = 0;
+    !w.has(o) && !w.has(42) || fails++;
+    w.add(o) === w || fails++;
+    w.has(o) && !w.has({}) || fails++;
+    w.delete(o) && !w.delete(o) && !w.delete('o') || fails++;
+    !w.has(o) || fails++;
+    try {
+      w.add(42);
+      fails++;
+    } catch (e) {
+      e instanceof TypeError || fails++;
+    }
+    Object.keys(w).length === 0 || fails++;
+    fails;
+    `,
+    expected: 0 },
+
+  /////////////////////////////////////////////////////////////////////////////
+  // Map and Set
+
+  { name: 'Map', src: `
+    var m = new Map([['a', 1]]);
+    var o = {};
+    var fails = 0;
+    m.size === 1 || fails++;
+    m.set(o, 'o') === m || fails++;
+    m.set(NaN, 'NaN').set(-0, 'zero').set('a', 2);
+    m.size === 4 || fails++;
+    m.get(o) === 'o' || fails++;
+    m.get({}) === undefined || fails++;
+    m.get(NaN) === 'NaN' || fails++;
+    m.get(0) === 'zero' || fails++;
+    m.get('a') === 2 || fails++;
+    m.has(o) && !m.has('o') || fails++;
+    m.delete(o) && !m.delete(o) || fails++;
+    m.size === 3 || fails++;
+    Object.keys(m).length === 0 || fails++;
+    var log = [];
+    m.forEach(function(v, k, map) {
+      log.push(String(k) + '=' + v);
+      map === m || fails++;
+      if (k === 'a') m.delete(0);
+    });
+    log.join() === 'a=2,NaN=NaN' || fails++;
+    m.clear();
+    m.size === 0 && !m.has('a') || fails++;
+    try {
+      Map();
+      fails++;
+    } catch (e) {
+      e instanceof TypeError || fails++;
+    }
+    fails;
+    `,
+    expected: 0 },
+
+  { name: 'Set', src: `
+    var s = new Set([1, 2, 2, NaN]);
+    var o = {};
+    var fails = 0;
+    s.size === 3 || fails++;
+    s.add(o) === s || fails++;
+    s.add(o).add(NaN).add(-0);
+    s.size === 5 || fails++;
+    s.has(o) && s.has(NaN) && s.has(0) && !s.has({}) || fails++;
+    s.delete(2) && !s.delete(2) || fails++;
+    var log = [];
+    s.forEach(function(v, v2, set) {
+      v === v2 || v !== v || fails++;
+      set === s || fails++;
+      log.push(typeof v);
+    });
+    log.join() === 'number,number,object,number' || fails++;
+    s.clear();
+    s.size === 0 || fails++;
+    try {
+      Set.prototype.has.call({}, 1);
+      fails++;
+    } catch (e) {
+      e instanceof TypeError || fails++;
+    }
+    fails;
+    `,
+    expected: 0 },
+
   /////////////////////////////////////////////////////////////////////////////
   // Thread and Thread.prototype:
 